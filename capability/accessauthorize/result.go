@@ -0,0 +1,22 @@
+package accessauthorize
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Request is the CID of the authorization request. Delegations issued when
+	// the request is confirmed reference it.
+	Request ipld.Link
+	// Expiration is the time in seconds since the Unix epoch that the request
+	// expires.
+	Expiration int64
+}
+
+type Failure = failure.Failure
@@ -0,0 +1,32 @@
+package accessclaim
+
+import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+const Ability = "access/claim"
+
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
+type Caveat struct{}
+
+var _ ucan.CaveatBuilder = (*Caveat)(nil)
+
+func (c Caveat) ToIPLD() (datamodel.Node, error) {
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
+}
+
+func NewCapability(agent did.DID, nb Caveat) ucan.Capability[Caveat] {
+	return ucan.NewCapability(Ability, agent.String(), nb)
+}
@@ -0,0 +1,23 @@
+package accessclaim
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Delegations are the delegations issued to the agent, keyed by CID. Each
+	// value is a delegation encoded as a CAR archive.
+	Delegations Delegations
+}
+
+type Delegations struct {
+	Keys   []string
+	Values map[string][]byte
+}
+
+type Failure = failure.Failure
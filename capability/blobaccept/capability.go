@@ -0,0 +1,54 @@
+package blobaccept
+
+import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Ability is issued by the service to itself as an effect of `space/blob/add`
+// to accept the blob once it has been uploaded.
+const Ability = "blob/accept"
+
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
+type Caveat struct {
+	// Space is the DID of the space the blob is stored in.
+	Space string
+	Blob  Blob
+	// Put is a promise for the result of the `http/put` invocation.
+	Put Promise
+}
+
+type Blob struct {
+	Digest []byte
+	Size   uint64
+}
+
+type Promise struct {
+	UcanAwait Await
+}
+
+type Await struct {
+	Selector string
+	Link     ipld.Link
+}
+
+var _ ucan.CaveatBuilder = (*Caveat)(nil)
+
+func (c Caveat) ToIPLD() (datamodel.Node, error) {
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
+}
+
+func NewCapability(provider did.DID, nb Caveat) ucan.Capability[Caveat] {
+	return ucan.NewCapability(Ability, provider.String(), nb)
+}
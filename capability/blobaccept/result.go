@@ -0,0 +1,18 @@
+package blobaccept
+
+import (
+	_ "embed"
+
+	"github.com/ipld/go-ipld-prime"
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Site is the CID of the location commitment for the accepted blob.
+	Site ipld.Link
+}
+
+type Failure = failure.Failure
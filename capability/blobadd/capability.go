@@ -0,0 +1,40 @@
+package blobadd
+
+import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+const Ability = "space/blob/add"
+
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
+type Caveat struct {
+	Blob Blob
+}
+
+// Blob describes the bytes to store. The Digest is the multihash of the bytes.
+type Blob struct {
+	Digest []byte
+	Size   uint64
+}
+
+var _ ucan.CaveatBuilder = (*Caveat)(nil)
+
+func (c Caveat) ToIPLD() (datamodel.Node, error) {
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
+}
+
+func NewCapability(space did.DID, nb Caveat) ucan.Capability[Caveat] {
+	return ucan.NewCapability(Ability, space.String(), nb)
+}
@@ -0,0 +1,31 @@
+package blobadd
+
+import (
+	_ "embed"
+
+	"github.com/ipld/go-ipld-prime"
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Site is a promise for the location commitment of the blob. It resolves
+	// to the `site` field of the successful `blob/accept` receipt.
+	Site Promise
+}
+
+// Promise is an awaited result of another invocation.
+type Promise struct {
+	UcanAwait Await
+}
+
+type Await struct {
+	// Selector is the path of the value in the awaited receipt e.g. `.out.ok`.
+	Selector string
+	// Link is the CID of the awaited invocation.
+	Link ipld.Link
+}
+
+type Failure = failure.Failure
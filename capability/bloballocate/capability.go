@@ -0,0 +1,45 @@
+package bloballocate
+
+import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Ability is issued by the service to itself as an effect of `space/blob/add`
+// in order to allocate space for the blob.
+const Ability = "blob/allocate"
+
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
+type Caveat struct {
+	// Space is the DID of the space the blob is allocated in.
+	Space string
+	Blob  Blob
+	// Cause is the CID of the `space/blob/add` invocation.
+	Cause ipld.Link
+}
+
+type Blob struct {
+	Digest []byte
+	Size   uint64
+}
+
+var _ ucan.CaveatBuilder = (*Caveat)(nil)
+
+func (c Caveat) ToIPLD() (datamodel.Node, error) {
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
+}
+
+func NewCapability(provider did.DID, nb Caveat) ucan.Capability[Caveat] {
+	return ucan.NewCapability(Ability, provider.String(), nb)
+}
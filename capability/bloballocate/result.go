@@ -0,0 +1,33 @@
+package bloballocate
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Size is the total bytes allocated in the space to accommodate the blob.
+	// May be zero if the blob is _already_ allocated in _this_ space.
+	Size uint64
+	// Address is where the blob should be uploaded to. It is not present if the
+	// blob is already stored by the service.
+	Address *Address
+}
+
+type Address struct {
+	Url     string
+	Headers Headers
+	// Expires is the time in seconds since the Unix epoch that the URL expires.
+	Expires uint64
+}
+
+type Headers struct {
+	Keys   []string
+	Values map[string]string
+}
+
+type Failure = failure.Failure
@@ -0,0 +1,56 @@
+package failure
+
+import "errors"
+
+var (
+	// ErrInsufficientStorage is matched by failures reporting that the space
+	// does not have enough storage capacity for the data e.g. because the space
+	// has no storage provider or its quota is exhausted.
+	ErrInsufficientStorage = errors.New("insufficient storage")
+	// ErrSpaceNotProvisioned is matched by failures reporting that the space is
+	// not known to the service i.e. it has not been provisioned.
+	ErrSpaceNotProvisioned = errors.New("space not provisioned")
+	// ErrUnauthorized is matched by failures reporting that the invocation was
+	// not authorized, typically because of missing or invalid proofs.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited is matched by failures reporting that the issuer or space
+	// has exceeded a rate limit.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrAccountPlanMissing is matched by failures reporting that the account
+	// has no billing plan, so cannot provision spaces.
+	ErrAccountPlanMissing = errors.New("account plan missing")
+)
+
+// names maps failure names reported by the service to sentinel errors.
+var names = map[string]error{
+	"InsufficientStorage": ErrInsufficientStorage,
+	"SpaceUnknown":        ErrSpaceNotProvisioned,
+	"Unauthorized":        ErrUnauthorized,
+	"RateLimited":         ErrRateLimited,
+	"AccountPlanMissing":  ErrAccountPlanMissing,
+}
+
+// Failure is the error result of an invocation, as reported by the service.
+// It can be compared to the sentinel errors in this package with `errors.Is`.
+type Failure struct {
+	Name    *string
+	Message string
+	Stack   *string
+}
+
+func (f Failure) Error() string {
+	if f.Name == nil || *f.Name == "" {
+		return f.Message
+	}
+	return *f.Name + ": " + f.Message
+}
+
+// Is reports whether the failure name corresponds to the target sentinel
+// error.
+func (f Failure) Is(target error) bool {
+	if f.Name == nil {
+		return false
+	}
+	err, ok := names[*f.Name]
+	return ok && err == target
+}
@@ -0,0 +1,27 @@
+package failure_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/storacha/go-w3up/capability/failure"
+	"github.com/storacha/go-w3up/capability/storeadd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailure(t *testing.T) {
+	name := "InsufficientStorage"
+	var err error = &storeadd.Failure{Name: &name, Message: "did:key:z6Mk has no storage provider"}
+
+	require.ErrorIs(t, err, failure.ErrInsufficientStorage)
+	require.NotErrorIs(t, err, failure.ErrUnauthorized)
+	require.Equal(t, "InsufficientStorage: did:key:z6Mk has no storage provider", err.Error())
+
+	var f *failure.Failure
+	require.True(t, errors.As(err, &f))
+	require.Equal(t, name, *f.Name)
+
+	unnamed := failure.Failure{Message: "boom"}
+	require.NotErrorIs(t, unnamed, failure.ErrInsufficientStorage)
+	require.Equal(t, "boom", unnamed.Error())
+}
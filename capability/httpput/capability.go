@@ -0,0 +1,103 @@
+package httpput
+
+import (
+	_ "embed"
+	"fmt"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Ability is issued by the service to a key derived from the blob digest as an
+// effect of `space/blob/add`. The client signs the receipt for this task after
+// it has uploaded the blob.
+const Ability = "http/put"
+
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
+type Caveat struct {
+	Body Body
+	// Url is a promise for the upload URL from the `blob/allocate` receipt.
+	Url Promise
+	// Headers is a promise for the upload headers from the `blob/allocate`
+	// receipt.
+	Headers Promise
+}
+
+type Body struct {
+	Digest []byte
+	Size   uint64
+}
+
+type Promise struct {
+	UcanAwait Await
+}
+
+type Await struct {
+	Selector string
+	Link     ipld.Link
+}
+
+var _ ucan.CaveatBuilder = (*Caveat)(nil)
+
+func (c Caveat) ToIPLD() (datamodel.Node, error) {
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
+}
+
+func NewCapability(provider ucan.Principal, nb Caveat) ucan.Capability[Caveat] {
+	return ucan.NewCapability(Ability, provider.DID().String(), nb)
+}
+
+// ExtractSigner extracts the signer the `http/put` task was delegated to. The
+// service includes the private key in the "keys" fact of the invocation so that
+// the client is able to issue a receipt for the task.
+func ExtractSigner(facts []ucan.Fact) (principal.Signer, error) {
+	for _, f := range facts {
+		v, ok := f["keys"]
+		if !ok {
+			continue
+		}
+		nd, ok := v.(datamodel.Node)
+		if !ok {
+			return nil, fmt.Errorf("unexpected keys fact type: %T", v)
+		}
+		idnd, err := nd.LookupByString("id")
+		if err != nil {
+			return nil, fmt.Errorf("looking up keys id: %s", err)
+		}
+		id, err := idnd.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("reading keys id: %s", err)
+		}
+		keysnd, err := nd.LookupByString("keys")
+		if err != nil {
+			return nil, fmt.Errorf("looking up keys: %s", err)
+		}
+		keynd, err := keysnd.LookupByString(id)
+		if err != nil {
+			return nil, fmt.Errorf("looking up key for %s: %s", id, err)
+		}
+		b, err := keynd.AsBytes()
+		if err != nil {
+			return nil, fmt.Errorf("reading key for %s: %s", id, err)
+		}
+		s, err := signer.Decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("decoding signer: %s", err)
+		}
+		if s.DID().String() != id {
+			return nil, fmt.Errorf("signer DID mismatch: %s != %s", s.DID(), id)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("missing keys fact")
+}
@@ -0,0 +1,29 @@
+package httpput
+
+import (
+	_ "embed"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+// Success is the (empty) result of a successful `http/put`.
+type Success struct{}
+
+func (ok Success) ToIPLD() (datamodel.Node, error) {
+	nb := basicnode.Prototype.Any.NewBuilder()
+	ma, err := nb.BeginMap(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := ma.Finish(); err != nil {
+		return nil, err
+	}
+	return nb.Build(), nil
+}
+
+type Failure = failure.Failure
@@ -0,0 +1,15 @@
+package provideradd
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+// Success is the (empty) result of a successful `provider/add`.
+type Success struct{}
+
+type Failure = failure.Failure
@@ -0,0 +1,20 @@
+package spaceinfo
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Did is the DID of the space.
+	Did string
+	// Providers are the DIDs of the storage providers the space is provisioned
+	// with.
+	Providers []string
+}
+
+type Failure = failure.Failure
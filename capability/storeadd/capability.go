@@ -1,6 +1,9 @@
 package storeadd
 
 import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/datamodel"
 	"github.com/storacha/go-ucanto/core/ipld"
 	"github.com/storacha/go-ucanto/did"
@@ -9,6 +12,9 @@ import (
 
 const Ability = "store/add"
 
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
 type Caveat struct {
 	Link   ipld.Link
 	Size   uint64
@@ -18,7 +24,11 @@ type Caveat struct {
 var _ ucan.CaveatBuilder = (*Caveat)(nil)
 
 func (c Caveat) ToIPLD() (datamodel.Node, error) {
-	return ipld.WrapWithRecovery(&c, nil)
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
 }
 
 func NewCapability(space did.DID, nb Caveat) ucan.Capability[Caveat] {
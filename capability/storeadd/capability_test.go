@@ -0,0 +1,25 @@
+package storeadd_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-w3up/capability/storeadd"
+	"github.com/stretchr/testify/require"
+)
+
+// The service decodes caveats with the lowercase keys of the JS capability
+// definitions, and rejects unknown keys. Absent optional fields are omitted.
+func TestCaveatEncoding(t *testing.T) {
+	link := helpers.RandomCID()
+	n, err := storeadd.Caveat{Link: link, Size: 128}.ToIPLD()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, dagjson.Encode(n.(schema.TypedNode).Representation(), &buf))
+	require.Equal(t, fmt.Sprintf(`{"link":{"/":"%s"},"size":128}`, link), buf.String())
+}
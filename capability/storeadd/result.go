@@ -4,6 +4,7 @@ import (
 	_ "embed"
 
 	"github.com/ipld/go-ipld-prime"
+	"github.com/storacha/go-w3up/capability/failure"
 )
 
 //go:embed result.ipldsch
@@ -30,8 +31,4 @@ type Headers struct {
 	Values map[string]string
 }
 
-type Failure struct {
-	Name    *string
-	Message string
-	Stack   *string
-}
+type Failure = failure.Failure
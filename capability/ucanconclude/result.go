@@ -0,0 +1,18 @@
+package ucanconclude
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Time is the time in milliseconds since the Unix epoch the receipt was
+	// concluded.
+	Time uint64
+}
+
+type Failure = failure.Failure
@@ -0,0 +1,42 @@
+package ucanrevoke
+
+import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+const Ability = "ucan/revoke"
+
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
+type Caveat struct {
+	// Ucan is the CID of the delegation to revoke.
+	Ucan ipld.Link
+	// Proof is the chain of proofs from the delegation to revoke to a delegation
+	// issued by the authority revoking it. It is empty if the authority issued
+	// the delegation being revoked.
+	Proof []ipld.Link
+}
+
+var _ ucan.CaveatBuilder = (*Caveat)(nil)
+
+func (c Caveat) ToIPLD() (datamodel.Node, error) {
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	if c.Proof == nil {
+		c.Proof = []ipld.Link{}
+	}
+	return ipld.WrapWithRecovery(&c, ts.TypeByName("Caveat"))
+}
+
+func NewCapability(authority did.DID, nb Caveat) ucan.Capability[Caveat] {
+	return ucan.NewCapability(Ability, authority.String(), nb)
+}
@@ -0,0 +1,18 @@
+package ucanrevoke
+
+import (
+	_ "embed"
+
+	"github.com/storacha/go-w3up/capability/failure"
+)
+
+//go:embed result.ipldsch
+var ResultSchema []byte
+
+type Success struct {
+	// Time is the time the delegation was revoked in seconds since the Unix
+	// epoch.
+	Time int64
+}
+
+type Failure = failure.Failure
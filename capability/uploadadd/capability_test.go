@@ -0,0 +1,28 @@
+package uploadadd_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-w3up/capability/uploadadd"
+	"github.com/stretchr/testify/require"
+)
+
+// The service decodes caveats with the lowercase keys of the JS capability
+// definitions, and rejects unknown keys. Absent optional fields are omitted.
+func TestCaveatEncoding(t *testing.T) {
+	root := helpers.RandomCID()
+	shard := helpers.RandomCID()
+	n, err := uploadadd.Caveat{Root: root, Shards: []ipld.Link{shard}}.ToIPLD()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, dagjson.Encode(n.(schema.TypedNode).Representation(), &buf))
+	expected := fmt.Sprintf(`{"root":{"/":"%s"},"shards":[{"/":"%s"}]}`, root, shard)
+	require.Equal(t, expected, buf.String())
+}
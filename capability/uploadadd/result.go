@@ -4,6 +4,7 @@ import (
 	_ "embed"
 
 	"github.com/ipld/go-ipld-prime"
+	"github.com/storacha/go-w3up/capability/failure"
 )
 
 //go:embed result.ipldsch
@@ -14,8 +15,4 @@ type Success struct {
 	Shards []ipld.Link
 }
 
-type Failure struct {
-	Name    *string
-	Message string
-	Stack   *string
-}
+type Failure = failure.Failure
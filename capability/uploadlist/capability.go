@@ -1,6 +1,9 @@
 package uploadlist
 
 import (
+	_ "embed"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/datamodel"
 	"github.com/storacha/go-ucanto/core/ipld"
 	"github.com/storacha/go-ucanto/did"
@@ -9,16 +12,44 @@ import (
 
 const Ability = "upload/list"
 
+//go:embed caveats.ipldsch
+var CaveatsSchema []byte
+
 type Caveat struct {
+	// Cursor is the cursor of the page to list, from a previous result. It is
+	// omitted if empty.
 	Cursor string
-	Size   int64
-	Pre    bool
+	// Size is the maximum number of uploads to list. It is omitted if zero.
+	Size int64
+	// Pre lists the page before the cursor. It is omitted if false.
+	Pre bool
 }
 
 var _ ucan.CaveatBuilder = (*Caveat)(nil)
 
+// caveatModel is the encoding of a `Caveat`, whose fields are all optional.
+type caveatModel struct {
+	Cursor *string
+	Size   *int64
+	Pre    *bool
+}
+
 func (c Caveat) ToIPLD() (datamodel.Node, error) {
-	return ipld.WrapWithRecovery(&c, nil)
+	ts, err := ipldprime.LoadSchemaBytes(CaveatsSchema)
+	if err != nil {
+		return nil, err
+	}
+	m := caveatModel{}
+	if c.Cursor != "" {
+		m.Cursor = &c.Cursor
+	}
+	if c.Size != 0 {
+		m.Size = &c.Size
+	}
+	if c.Pre {
+		m.Pre = &c.Pre
+	}
+	return ipld.WrapWithRecovery(&m, ts.TypeByName("Caveat"))
 }
 
 func NewCapability(space did.DID, nb Caveat) ucan.Capability[Caveat] {
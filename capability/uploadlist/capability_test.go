@@ -0,0 +1,29 @@
+package uploadlist_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-w3up/capability/uploadlist"
+	"github.com/stretchr/testify/require"
+)
+
+// The service decodes caveats with the lowercase keys of the JS capability
+// definitions, and rejects unknown keys. Absent optional fields are omitted.
+func TestCaveatEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		caveat   uploadlist.Caveat
+		expected string
+	}{
+		{uploadlist.Caveat{}, `{}`},
+		{uploadlist.Caveat{Cursor: "abc", Size: 10, Pre: true}, `{"cursor":"abc","pre":true,"size":10}`},
+	} {
+		n, err := tc.caveat.ToIPLD()
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, dagjson.Encode(n.(schema.TypedNode).Representation(), &buf))
+		require.Equal(t, tc.expected, buf.String())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 
 	"github.com/ipld/go-ipld-prime"
+	"github.com/storacha/go-w3up/capability/failure"
 )
 
 //go:embed result.ipldsch
@@ -24,8 +25,4 @@ type Item struct {
 	UpdatedAt  string
 }
 
-type Failure struct {
-	Name    *string
-	Message string
-	Stack   *string
-}
+type Failure = failure.Failure
@@ -0,0 +1,97 @@
+package sharding
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/core/ipld"
+)
+
+// Shard is a CAR shard that has been hashed, whose bytes can be read again to
+// store it. Shards must be closed once stored.
+type Shard struct {
+	// Digest is the SHA-256 multihash of the shard bytes.
+	Digest multihash.Multihash
+	// Size is the byte length of the shard.
+	Size uint64
+	src  io.ReaderAt
+	// file is the temporary file a spooled shard is backed by.
+	file *os.File
+}
+
+// Link returns the CAR CID of the shard.
+func (s *Shard) Link() ipld.Link {
+	return cidlink.Link{Cid: cid.NewCidV1(uint64(multicodec.Car), s.Digest)}
+}
+
+// Reader returns a reader of the shard bytes from the start. It may be called
+// more than once, e.g. to retry storing the shard.
+func (s *Shard) Reader() io.Reader {
+	return io.NewSectionReader(s.src, 0, int64(s.Size))
+}
+
+// Close removes the temporary file backing a spooled shard. It does not close
+// the source of a shard created with `NewShard`.
+func (s *Shard) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	if rerr := os.Remove(s.file.Name()); err == nil {
+		err = rerr
+	}
+	s.file = nil
+	return err
+}
+
+// NewShard hashes the `size` bytes of a seekable source, such as a CAR file
+// small enough to be stored as a single shard. The source is read once to
+// hash it and again each time the shard is read, so it is never held in
+// memory.
+func NewShard(src io.ReaderAt, size int64) (*Shard, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, io.NewSectionReader(src, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("hashing shard: %s", err)
+	}
+	if n != size {
+		return nil, fmt.Errorf("hashing shard: read %d of %d bytes", n, size)
+	}
+	digest, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return nil, fmt.Errorf("encoding digest: %s", err)
+	}
+	return &Shard{Digest: digest, Size: uint64(size), src: src}, nil
+}
+
+// Spool writes a shard, as yielded by a sharder, to a temporary file in `dir`
+// while hashing it, so that memory use is bounded regardless of the shard
+// size. If `dir` is empty the default directory for temporary files is used.
+func Spool(r io.Reader, dir string) (*Shard, error) {
+	f, err := os.CreateTemp(dir, "w3up-shard-*.car")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %s", err)
+	}
+	shd := &Shard{src: f, file: f}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		shd.Close()
+		return nil, fmt.Errorf("spooling shard: %w", err)
+	}
+	digest, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		shd.Close()
+		return nil, fmt.Errorf("encoding digest: %s", err)
+	}
+	shd.Digest = digest
+	shd.Size = uint64(n)
+	return shd, nil
+}
@@ -0,0 +1,95 @@
+package sharding_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-w3up/car/sharding"
+	"github.com/stretchr/testify/require"
+)
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("boom") }
+
+func TestSpool(t *testing.T) {
+	blocks := []ipld.Block{
+		randomRawBlock(t, 4000),
+		randomRawBlock(t, 4000),
+	}
+	iterator := func(yield func(ipld.Block, error) bool) {
+		for _, b := range blocks {
+			if !yield(b, nil) {
+				return
+			}
+		}
+	}
+
+	shards, err := sharding.NewSharder(nil, iterator)
+	require.NoError(t, err)
+
+	for s, err := range shards {
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		shd, err := sharding.Spool(s, dir)
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(shd.Reader())
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(b)), shd.Size)
+
+		mh, err := multihash.Sum(b, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		require.Equal(t, mh, shd.Digest)
+		require.Equal(t, uint64(0x0202), shd.Link().(cidlink.Link).Prefix().Codec)
+
+		// can be read again
+		again, err := io.ReadAll(shd.Reader())
+		require.NoError(t, err)
+		require.Equal(t, b, again)
+
+		require.NoError(t, shd.Close())
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	}
+
+	t.Run("read error", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := sharding.Spool(io.MultiReader(bytes.NewReader([]byte("car")), errReader{}), dir)
+		require.ErrorContains(t, err, "boom")
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
+
+func TestNewShard(t *testing.T) {
+	b := []byte("not really a CAR")
+	shd, err := sharding.NewShard(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(b)), shd.Size)
+
+	mh, err := multihash.Sum(b, multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	require.Equal(t, mh, shd.Digest)
+
+	for range 2 {
+		r, err := io.ReadAll(shd.Reader())
+		require.NoError(t, err)
+		require.Equal(t, b, r)
+	}
+	require.NoError(t, shd.Close())
+
+	t.Run("short source", func(t *testing.T) {
+		_, err := sharding.NewShard(bytes.NewReader(b), int64(len(b)+1))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/accessauthorize"
+	"github.com/storacha/go-w3up/capability/accessclaim"
+	"github.com/storacha/go-w3up/proof"
+)
+
+// ErrAuthorizationExpired is returned by `Login` when the authorization
+// request expires before it is confirmed.
+var ErrAuthorizationExpired = errors.New("authorization request expired")
+
+// AccountDID returns the `did:mailto:` DID of the account identified by the
+// email address.
+func AccountDID(email string) (did.DID, error) {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" || domain == "" || strings.Contains(domain, "@") {
+		return did.DID{}, fmt.Errorf("invalid email address: %s", email)
+	}
+	return did.Parse(fmt.Sprintf("did:mailto:%s:%s", escapeComponent(domain), escapeComponent(local)))
+}
+
+// escapeComponent escapes a string like JavaScript `encodeURIComponent`, so
+// that account DIDs match those derived by other w3up clients.
+func escapeComponent(s string) string {
+	const unreserved = "-_.!~*'()"
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte(unreserved, c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// AccessAuthorize requests authorization from an account for the agent to
+// invoke capabilities. The service emails the account a link that confirms the
+// request, after which the delegations can be claimed with `AccessClaim`.
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation. It
+// is the agent requesting authorization.
+//
+// The `account` is the DID of the account to request authorization from. It is
+// typically a `did:mailto:` DID, see `AccountDID`.
+func AccessAuthorize(ctx context.Context, issuer principal.Signer, account did.DID, options ...Option) (receipt.Receipt[*accessauthorize.Success, *accessauthorize.Failure], error) {
+	iss := account.String()
+	return Invoke[accessauthorize.Caveat, *accessauthorize.Success, *accessauthorize.Failure](
+		ctx,
+		issuer,
+		accessauthorize.NewCapability(issuer.DID(), accessauthorize.Caveat{
+			Iss: &iss,
+			Att: []accessauthorize.CapabilityRequest{{Can: "*"}},
+		}),
+		accessauthorize.ResultSchema,
+		options...,
+	)
+}
+
+// AccessClaim claims the delegations the service holds for the agent. See
+// `ClaimedDelegations` to decode them.
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation. It
+// is the agent the delegations are issued to.
+func AccessClaim(ctx context.Context, issuer principal.Signer, options ...Option) (receipt.Receipt[*accessclaim.Success, *accessclaim.Failure], error) {
+	return Invoke[accessclaim.Caveat, *accessclaim.Success, *accessclaim.Failure](
+		ctx,
+		issuer,
+		accessclaim.NewCapability(issuer.DID(), accessclaim.Caveat{}),
+		accessclaim.ResultSchema,
+		options...,
+	)
+}
+
+// ClaimedDelegations decodes the delegations in an `access/claim` result.
+func ClaimedDelegations(claimed *accessclaim.Success) ([]delegation.Delegation, error) {
+	var dlgs []delegation.Delegation
+	for _, k := range claimed.Delegations.Keys {
+		dlg, err := delegation.Extract(claimed.Delegations.Values[k])
+		if err != nil {
+			return nil, fmt.Errorf("extracting delegation %s: %s", k, err)
+		}
+		dlgs = append(dlgs, dlg)
+	}
+	return dlgs, nil
+}
+
+// Login authorizes the agent to act on behalf of an account. It requests
+// authorization with `AccessAuthorize` and then polls `AccessClaim` until the
+// request is confirmed (e.g. by clicking the link in the email sent to the
+// account), the request expires or the context is canceled.
+//
+// The claimed delegations issued by the account to the agent for this request,
+// along with the `ucan/attest` session proofs issued by the service for them,
+// are returned. Delegations from earlier requests, e.g. a previous login, and
+// expired delegations are ignored.
+// They are also added to the proof store if one is configured with
+// `WithProofStore`.
+//
+// The time to wait between claims can be configured with `WithPollInterval`
+// and the maximum number of claims with `WithPollRetries`. By default claims
+// are made every `DefaultPollInterval` until the request expires.
+func Login(ctx context.Context, issuer principal.Signer, account did.DID, options ...Option) ([]delegation.Delegation, error) {
+	cfg := ClientConfig{pollInterval: DefaultPollInterval}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	rcpt, err := AccessAuthorize(ctx, issuer, account, options...)
+	if err != nil {
+		return nil, err
+	}
+	authorized, authorizeFailure := result.Unwrap(rcpt.Out())
+	if authorizeFailure != nil {
+		return nil, fmt.Errorf("%s: %w", accessauthorize.Ability, authorizeFailure)
+	}
+
+	expiration := time.Unix(authorized.Expiration, 0)
+	for attempt := 1; ; attempt++ {
+		rcpt, err := AccessClaim(ctx, issuer, options...)
+		if err != nil {
+			return nil, err
+		}
+		claimed, claimFailure := result.Unwrap(rcpt.Out())
+		if claimFailure != nil {
+			return nil, fmt.Errorf("%s: %w", accessclaim.Ability, claimFailure)
+		}
+		dlgs, err := ClaimedDelegations(claimed)
+		if err != nil {
+			return nil, err
+		}
+
+		now := ucan.UTCUnixTimestamp(time.Now().Unix())
+		if session := sessionDelegations(dlgs, issuer.DID(), account, authorized.Request, now); len(session) > 0 {
+			if cfg.prfs != nil {
+				if err := cfg.prfs.Add(session...); err != nil {
+					return nil, fmt.Errorf("storing delegations: %s", err)
+				}
+			}
+			return session, nil
+		}
+
+		if cfg.pollRetries > 0 && attempt >= cfg.pollRetries {
+			return nil, fmt.Errorf("authorization not confirmed after %d attempts", attempt)
+		}
+		if authorized.Expiration > 0 && !time.Now().Before(expiration) {
+			return nil, ErrAuthorizationExpired
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(cfg.pollInterval):
+		}
+	}
+}
+
+// RequestFact is the fact the service adds to the delegations it issues when
+// an authorization request is confirmed, linking them to the request.
+const RequestFact = "access/request"
+
+// sessionDelegations returns the delegations issued by the account to the
+// agent for the authorization request, along with the attestations for them.
+// Expired delegations are skipped. It returns nil if there are no delegations
+// from the account for the request.
+func sessionDelegations(dlgs []delegation.Delegation, agent did.DID, account did.DID, request ipld.Link, now ucan.UTCUnixTimestamp) []delegation.Delegation {
+	authorized := map[string]struct{}{}
+	var session []delegation.Delegation
+	for _, dlg := range dlgs {
+		if dlg.Issuer().DID() != account || dlg.Audience().DID() != agent {
+			continue
+		}
+		if isExpired(dlg, now) || !forRequest(dlg, request) {
+			continue
+		}
+		authorized[dlg.Link().String()] = struct{}{}
+		session = append(session, dlg)
+	}
+	if len(session) == 0 {
+		return nil
+	}
+
+	for _, dlg := range dlgs {
+		if dlg.Audience().DID() != agent || isExpired(dlg, now) {
+			continue
+		}
+		for _, link := range proof.Attests(dlg) {
+			if _, ok := authorized[link.String()]; ok {
+				session = append(session, dlg)
+				break
+			}
+		}
+	}
+	return session
+}
+
+func isExpired(dlg delegation.Delegation, now ucan.UTCUnixTimestamp) bool {
+	exp := dlg.Expiration()
+	return exp != nil && *exp <= now
+}
+
+// forRequest reports whether the delegation has a `RequestFact` linking it to
+// the authorization request.
+func forRequest(dlg delegation.Delegation, request ipld.Link) bool {
+	for _, f := range dlg.Facts() {
+		var link ipld.Link
+		switch v := f[RequestFact].(type) {
+		case ipld.Link:
+			link = v
+		case datamodel.Node:
+			l, err := v.AsLink()
+			if err != nil {
+				continue
+			}
+			link = l
+		}
+		if link != nil && link.String() == request.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	fdm "github.com/storacha/go-ucanto/core/result/failure/datamodel"
+	"github.com/storacha/go-ucanto/core/result/ok"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	psigner "github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/accessauthorize"
+	"github.com/storacha/go-w3up/capability/accessclaim"
+	xfailure "github.com/storacha/go-w3up/capability/failure"
+	"github.com/storacha/go-w3up/capability/provideradd"
+	"github.com/storacha/go-w3up/client"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/stretchr/testify/require"
+)
+
+type authorizeSuccess struct {
+	request ipld.Link
+}
+
+func (ok authorizeSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 2, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "request", qp.Link(ok.request))
+		qp.MapEntry(ma, "expiration", qp.Int(time.Now().Add(time.Hour).Unix()))
+	})
+}
+
+type claimSuccess struct {
+	dlgs []delegation.Delegation
+}
+
+func (ok claimSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "delegations", qp.Map(int64(len(ok.dlgs)), func(ma datamodel.MapAssembler) {
+			for _, d := range ok.dlgs {
+				b, err := io.ReadAll(delegation.Archive(d))
+				if err != nil {
+					panic(err)
+				}
+				qp.MapEntry(ma, d.Link().String(), qp.Bytes(b))
+			}
+		}))
+	})
+}
+
+type attestCaveat struct {
+	proof ipld.Link
+}
+
+func (c attestCaveat) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "proof", qp.Link(c.proof))
+	})
+}
+
+func TestAccountDID(t *testing.T) {
+	account, err := client.AccountDID("alice+test@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "did:mailto:example.com:alice%2Btest", account.String())
+
+	for _, email := range []string{"alice", "@example.com", "alice@", "a@b@example.com"} {
+		_, err := client.AccountDID(email)
+		require.Error(t, err, email)
+	}
+}
+
+type requestFact struct {
+	request ipld.Link
+}
+
+func (f requestFact) ToIPLD() (map[string]datamodel.Node, error) {
+	if f.request == nil {
+		return map[string]datamodel.Node{}, nil
+	}
+	return map[string]datamodel.Node{client.RequestFact: basicnode.NewLink(f.request)}, nil
+}
+
+// sessionDelegation delegates all capabilities of the account to the agent, as
+// the service does when the authorization request is confirmed.
+func sessionDelegation(t *testing.T, account ucan.Signer, agent ucan.Principal, request ipld.Link, options ...delegation.Option) delegation.Delegation {
+	t.Helper()
+	return helpers.Must(delegation.Delegate(
+		account,
+		agent,
+		[]ucan.Capability[ucan.NoCaveats]{ucan.NewCapability("*", "ucan:*", ucan.NoCaveats{})},
+		append(options, delegation.WithFacts([]ucan.FactBuilder{requestFact{request}}))...,
+	))
+}
+
+// newAccessService creates a local stand-in for the service that confirms
+// authorization requests once they have been claimed `delay` times.
+func newAccessService(t *testing.T, account ucan.Signer, delay int, methods ...serviceMethod) (client.Option, *int) {
+	t.Helper()
+
+	claims := 0
+	var request ipld.Link
+	earlierRequest := helpers.RandomCID()
+	conn := newTestConnection(t, append([]serviceMethod{
+		provide(accessauthorize.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (authorizeSuccess, fx.Effects, error) {
+			iss := helpers.Must(helpers.Must(cap.Nb().LookupByString("iss")).AsString())
+			require.Equal(t, account.DID().String(), iss)
+			request = inv.Link()
+			return authorizeSuccess{request}, nil, nil
+		}),
+		provide(accessclaim.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (claimSuccess, fx.Effects, error) {
+			claims++
+			agent := inv.Issuer()
+			// delegations from an earlier request and an expired delegation
+			// are claimable before the request is confirmed, and must be
+			// ignored
+			dlgs := []delegation.Delegation{
+				sessionDelegation(t, account, agent, earlierRequest, delegation.WithNoExpiration()),
+				sessionDelegation(t, account, agent, request, delegation.WithExpiration(int(time.Now().Add(-time.Minute).Unix()))),
+			}
+			if request == nil || claims <= delay {
+				return claimSuccess{dlgs}, nil, nil
+			}
+			dlg := sessionDelegation(t, account, agent, request, delegation.WithNoExpiration())
+			attestation := helpers.Must(delegation.Delegate(
+				fixtures.Service,
+				agent,
+				[]ucan.Capability[attestCaveat]{ucan.NewCapability(proof.AttestAbility, fixtures.Service.DID().String(), attestCaveat{dlg.Link()})},
+				delegation.WithNoExpiration(),
+				delegation.WithFacts([]ucan.FactBuilder{requestFact{request}}),
+			))
+			// a delegation to another agent is not part of the session
+			other := sessionDelegation(t, account, fixtures.Mallory, request, delegation.WithNoExpiration())
+			return claimSuccess{append(dlgs, dlg, attestation, other)}, nil, nil
+		}),
+	}, methods...)...)
+	return client.WithConnection(conn), &claims
+}
+
+func TestLogin(t *testing.T) {
+	account := helpers.Must(psigner.Wrap(helpers.Must(signer.Generate()), helpers.Must(client.AccountDID("alice@example.com"))))
+
+	t.Run("stores session proofs", func(t *testing.T) {
+		conn, claims := newAccessService(t, account, 2)
+		store := proof.NewMemoryStore()
+
+		dlgs, err := client.Login(
+			context.Background(),
+			fixtures.Alice,
+			account.DID(),
+			conn,
+			client.WithProofStore(store),
+			client.WithPollInterval(time.Millisecond),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 3, *claims)
+
+		require.Len(t, dlgs, 2)
+		require.Equal(t, account.DID(), dlgs[0].Issuer().DID())
+		require.Equal(t, fixtures.Alice.DID(), dlgs[0].Audience().DID())
+		require.Equal(t, proof.AttestAbility, dlgs[1].Capabilities()[0].Can())
+		require.Equal(t, []ipld.Link{dlgs[0].Link()}, proof.Attests(dlgs[1]))
+
+		for _, d := range dlgs {
+			_, ok, err := store.Get(d.Link())
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		conn, claims := newAccessService(t, account, 10)
+
+		_, err := client.Login(
+			context.Background(),
+			fixtures.Alice,
+			account.DID(),
+			conn,
+			client.WithPollInterval(time.Millisecond),
+			client.WithPollRetries(3),
+		)
+		require.Error(t, err)
+		require.Equal(t, 3, *claims)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		conn, _ := newAccessService(t, account, 10)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.Login(ctx, fixtures.Alice, account.DID(), conn, client.WithPollInterval(time.Hour))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// planMissing is a service method that fails like the service does when the
+// account has no billing plan.
+var planMissing = serviceMethod{provideradd.Ability, func(inv invocation.Invocation) (result.Result[ipld.Builder, ipld.Builder], fx.Effects, error) {
+	name := "AccountPlanMissing"
+	x := failure.FromFailureModel(fdm.FailureModel{Name: &name, Message: "account has no payment plan"})
+	return result.Error[ipld.Builder, ipld.Builder](x), nil, nil
+}}
+
+func TestProvision(t *testing.T) {
+	account := helpers.Must(psigner.Wrap(helpers.Must(signer.Generate()), helpers.Must(client.AccountDID("alice@example.com"))))
+	space := helpers.Must(signer.Generate())
+
+	t.Run("provisions space", func(t *testing.T) {
+		var provisioned ipld.Node
+		conn, _ := newAccessService(t, account, 0,
+			provide(provideradd.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (ok.Unit, fx.Effects, error) {
+				require.Equal(t, account.DID().String(), cap.With())
+				provisioned = cap.Nb()
+				return ok.Unit{}, nil, nil
+			}),
+		)
+
+		c := helpers.Must(client.NewClient(fixtures.Alice, conn, client.WithProofStore(proof.NewMemoryStore()), client.WithSpace(space.DID())))
+		_, err := c.Login(context.Background(), account.DID())
+		require.NoError(t, err)
+
+		rcpt, err := c.Provision(context.Background(), account.DID())
+		require.NoError(t, err)
+		_, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+
+		consumer := helpers.Must(helpers.Must(provisioned.LookupByString("consumer")).AsString())
+		require.Equal(t, space.DID().String(), consumer)
+		provider := helpers.Must(helpers.Must(provisioned.LookupByString("provider")).AsString())
+		require.Equal(t, fixtures.Service.DID().String(), provider)
+	})
+
+	t.Run("account plan missing", func(t *testing.T) {
+		conn := newTestConnection(t, planMissing)
+		c := helpers.Must(client.NewClient(fixtures.Alice, client.WithConnection(conn), client.WithSpace(space.DID())))
+
+		rcpt, err := c.Provision(context.Background(), account.DID())
+		require.NoError(t, err)
+		_, x := result.Unwrap(rcpt.Out())
+		require.ErrorIs(t, x, xfailure.ErrAccountPlanMissing)
+	})
+}
@@ -0,0 +1,294 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/capability/blobaccept"
+	"github.com/storacha/go-w3up/capability/blobadd"
+	"github.com/storacha/go-w3up/capability/provideradd"
+	"github.com/storacha/go-w3up/capability/spaceinfo"
+	"github.com/storacha/go-w3up/capability/storeadd"
+	"github.com/storacha/go-w3up/capability/ucanconclude"
+	"github.com/storacha/go-w3up/capability/ucanrevoke"
+	"github.com/storacha/go-w3up/capability/uploadadd"
+	"github.com/storacha/go-w3up/capability/uploadlist"
+	"github.com/storacha/go-w3up/proof"
+)
+
+// Client is an agent that invokes capabilities on the service. It holds the
+// signer, connection, proofs and default space so they do not need to be
+// passed to every invocation.
+type Client struct {
+	issuer   principal.Signer
+	conn     client.Connection
+	rcptsURL *url.URL
+	proofs   []delegation.Delegation
+	store    proof.Store
+	space    did.DID
+}
+
+// NewClient creates a new client for the passed issuer. The connection, proofs,
+// proof store and default space may be configured with `WithConnection`,
+// `WithProofs`, `WithProofStore` and `WithSpace`. The connection defaults to
+// `DefaultConnection`.
+//
+// Receipts are fetched from the receipts endpoint of the connection (see
+// `ReceiptsEndpoint`), unless one is configured with `WithReceiptsEndpoint`.
+func NewClient(issuer principal.Signer, options ...Option) (*Client, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	rcptsURL := cfg.rcptsURL
+	if rcptsURL == nil {
+		rcptsURL, _ = ReceiptsEndpoint(cfg.conn)
+	}
+	return &Client{
+		issuer:   issuer,
+		conn:     cfg.conn,
+		rcptsURL: rcptsURL,
+		proofs:   cfg.prf,
+		store:    cfg.prfs,
+		space:    cfg.spc,
+	}, nil
+}
+
+// Issuer returns the signer that issues invocations.
+func (c *Client) Issuer() principal.Signer {
+	return c.issuer
+}
+
+// Connection returns the connection invocations are executed on.
+func (c *Client) Connection() client.Connection {
+	return c.conn
+}
+
+// ReceiptsEndpoint returns the URL receipts are fetched from. It is nil if none
+// was configured and the connection is not over HTTP.
+func (c *Client) ReceiptsEndpoint() *url.URL {
+	return c.rcptsURL
+}
+
+// Proofs returns the proofs attached to invocations.
+func (c *Client) Proofs() []delegation.Delegation {
+	return c.proofs
+}
+
+// AddProofs adds proofs to attach to invocations.
+func (c *Client) AddProofs(proofs ...delegation.Delegation) {
+	c.proofs = append(c.proofs, proofs...)
+}
+
+// ProofStore returns the store proofs are selected from. It is nil if the
+// client was not configured with a proof store.
+func (c *Client) ProofStore() proof.Store {
+	return c.store
+}
+
+// Space returns the default space capabilities are invoked on.
+func (c *Client) Space() did.DID {
+	return c.space
+}
+
+// SetSpace sets the default space capabilities are invoked on.
+func (c *Client) SetSpace(space did.DID) {
+	c.space = space
+}
+
+// options returns the space to invoke a capability on, along with the options
+// to pass to the invocation. Options passed to a method take precedence over
+// the client state, except proofs passed with `WithProof` or `WithProofs`,
+// which are attached in addition to the proofs of the client.
+func (c *Client) options(options []Option) (did.DID, []Option, error) {
+	cfg := ClientConfig{spc: c.space}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return did.DID{}, nil, err
+		}
+	}
+	if !cfg.spc.Defined() {
+		return did.DID{}, nil, fmt.Errorf("no space configured")
+	}
+
+	opts := []Option{WithConnection(c.conn)}
+	if c.store != nil {
+		opts = append(opts, WithProofStore(c.store))
+	}
+	opts = append(opts, options...)
+	if prfs := mergeProofs(c.proofs, cfg.prf); len(prfs) > 0 {
+		opts = append(opts, WithProofs(prfs))
+	}
+
+	return cfg.spc, opts, nil
+}
+
+// mergeProofs returns the proofs of both lists, without duplicates.
+func mergeProofs(a, b []delegation.Delegation) []delegation.Delegation {
+	var prfs []delegation.Delegation
+	seen := map[string]struct{}{}
+	for _, prf := range append(append([]delegation.Delegation{}, a...), b...) {
+		if _, ok := seen[prf.Link().String()]; ok {
+			continue
+		}
+		seen[prf.Link().String()] = struct{}{}
+		prfs = append(prfs, prf)
+	}
+	return prfs
+}
+
+// receiptOptions returns the options to fetch receipts with. Options passed to
+// a method take precedence over the client state.
+func (c *Client) receiptOptions(options []Option) []Option {
+	opts := []Option{WithConnection(c.conn)}
+	if c.rcptsURL != nil {
+		opts = append(opts, WithReceiptsEndpoint(c.rcptsURL))
+	}
+	return append(opts, options...)
+}
+
+// FetchReceipt fetches the receipt for a task from the receipts endpoint of the
+// client. See `FetchReceipt`.
+func (c *Client) FetchReceipt(ctx context.Context, task ipld.Link, options ...Option) (receipt.AnyReceipt, error) {
+	return FetchReceipt(ctx, task, c.receiptOptions(options)...)
+}
+
+// PollReceipt polls the receipts endpoint of the client for the receipt for a
+// task. See `PollReceipt`.
+func (c *Client) PollReceipt(ctx context.Context, task ipld.Link, options ...Option) (receipt.AnyReceipt, error) {
+	return PollReceipt(ctx, task, c.receiptOptions(options)...)
+}
+
+// FollowEffects resolves the receipts for the effects of a receipt, e.g.
+// `rcpt.Fx()`, polling the receipts endpoint of the client for those that are
+// not concluded. See `FollowEffects`.
+func (c *Client) FollowEffects(ctx context.Context, effects fx.Effects, options ...Option) (EffectReceipts, error) {
+	return followEffects(ctx, effects, c.receiptOptions(options))
+}
+
+// BlobAdd stores a blob in the space. See `BlobAdd`.
+func (c *Client) BlobAdd(ctx context.Context, params blobadd.Caveat, options ...Option) (receipt.Receipt[*blobadd.Success, *blobadd.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	return BlobAdd(ctx, c.issuer, space, params, opts...)
+}
+
+// ConcludeHTTPPut signals to the service that a blob has been uploaded. See
+// `ConcludeHTTPPut`.
+func (c *Client) ConcludeHTTPPut(ctx context.Context, put invocation.Invocation, options ...Option) (receipt.Receipt[*ucanconclude.Success, *ucanconclude.Failure], error) {
+	opts := append([]Option{WithConnection(c.conn)}, options...)
+	return ConcludeHTTPPut(ctx, c.issuer, put, opts...)
+}
+
+// PollBlobAcceptReceipt polls the receipts endpoint of the client for the
+// receipt for a `blob/accept` task, e.g. `BlobAddEffects.Accept`, when it was
+// not concluded in the `space/blob/add` response. See `PollReceipt`.
+func (c *Client) PollBlobAcceptReceipt(ctx context.Context, task ipld.Link, options ...Option) (receipt.Receipt[*blobaccept.Success, *blobaccept.Failure], error) {
+	reader, err := blobaccept.NewReceiptReader()
+	if err != nil {
+		return nil, err
+	}
+	return PollReceiptWithReader(ctx, task, reader, c.receiptOptions(options)...)
+}
+
+// StoreAdd stores a DAG encoded as a CAR file in the space. See `StoreAdd`.
+func (c *Client) StoreAdd(ctx context.Context, params storeadd.Caveat, options ...Option) (receipt.Receipt[*storeadd.Success, *storeadd.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	return StoreAdd(ctx, c.issuer, space, params, opts...)
+}
+
+// UploadAdd registers an "upload" in the space. See `UploadAdd`.
+func (c *Client) UploadAdd(ctx context.Context, params uploadadd.Caveat, options ...Option) (receipt.Receipt[*uploadadd.Success, *uploadadd.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	return UploadAdd(ctx, c.issuer, space, params, opts...)
+}
+
+// UploadList returns a paginated list of uploads in the space. See
+// `UploadList`.
+func (c *Client) UploadList(ctx context.Context, params uploadlist.Caveat, options ...Option) (receipt.Receipt[*uploadlist.Success, *uploadlist.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	return UploadList(ctx, c.issuer, space, params, opts...)
+}
+
+// StoreAddBatch stores multiple DAGs encoded as CAR files in the space in a
+// single request. See `StoreAddBatch`.
+func (c *Client) StoreAddBatch(ctx context.Context, params []storeadd.Caveat, options ...Option) ([]receipt.Receipt[*storeadd.Success, *storeadd.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	return StoreAddBatch(ctx, c.issuer, space, params, opts...)
+}
+
+// SpaceInfo returns information about the space. See `SpaceInfo`.
+func (c *Client) SpaceInfo(ctx context.Context, options ...Option) (receipt.Receipt[*spaceinfo.Success, *spaceinfo.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	return SpaceInfo(ctx, c.issuer, space, opts...)
+}
+
+// Provision provisions the space with the service as its storage provider,
+// billed to the account. The proofs the agent obtained when logging in to the
+// account are used. See `ProviderAdd`.
+func (c *Client) Provision(ctx context.Context, account did.DID, options ...Option) (receipt.Receipt[*provideradd.Success, *provideradd.Failure], error) {
+	space, opts, err := c.options(options)
+	if err != nil {
+		return nil, err
+	}
+	params := provideradd.Caveat{
+		Provider: c.conn.ID().DID().String(),
+		Consumer: space.String(),
+	}
+	return ProviderAdd(ctx, c.issuer, account, params, opts...)
+}
+
+// Login authorizes the agent to act on behalf of an account. The delegations
+// are added to the proof store of the client or, if it has none, to the proofs
+// attached to invocations. See `Login`.
+func (c *Client) Login(ctx context.Context, account did.DID, options ...Option) ([]delegation.Delegation, error) {
+	opts := []Option{WithConnection(c.conn)}
+	if c.store != nil {
+		opts = append(opts, WithProofStore(c.store))
+	}
+	dlgs, err := Login(ctx, c.issuer, account, append(opts, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	if c.store == nil {
+		c.AddProofs(dlgs...)
+	}
+	return dlgs, nil
+}
+
+// Revoke revokes the delegation with the passed CID, which must be in the proof
+// store of the client. The delegation is marked as revoked in the store once
+// the service confirms the revocation. See `Revoke`.
+func (c *Client) Revoke(ctx context.Context, link ipld.Link, options ...Option) (receipt.Receipt[*ucanrevoke.Success, *ucanrevoke.Failure], error) {
+	opts := []Option{WithConnection(c.conn)}
+	if c.store != nil {
+		opts = append(opts, WithProofStore(c.store))
+	}
+	return RevokeLink(ctx, c.issuer, link, append(opts, options...)...)
+}
@@ -0,0 +1,153 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/uploadlist"
+	"github.com/storacha/go-w3up/client"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/stretchr/testify/require"
+)
+
+type uploadListSuccess struct {
+	space string
+}
+
+func (ok uploadListSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 3, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "results", qp.List(0, func(la datamodel.ListAssembler) {}))
+		qp.MapEntry(ma, "cursor", qp.String(ok.space))
+		qp.MapEntry(ma, "size", qp.Int(0))
+	})
+}
+
+func TestClient(t *testing.T) {
+	// the service echoes the space in the cursor
+	conn := newTestConnection(t, provide(uploadlist.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (uploadListSuccess, fx.Effects, error) {
+		return uploadListSuccess{cap.With()}, nil, nil
+	}))
+
+	// alice is a space that delegates to bob, the agent
+	proofDlg := helpers.Must(delegation.Delegate(
+		fixtures.Alice,
+		fixtures.Bob,
+		[]ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability("upload/*", fixtures.Alice.DID().String(), ucan.NoCaveats{}),
+		},
+	))
+
+	t.Run("default space", func(t *testing.T) {
+		c, err := client.NewClient(
+			fixtures.Bob,
+			client.WithConnection(conn),
+			client.WithProofs([]delegation.Delegation{proofDlg}),
+			client.WithSpace(fixtures.Alice.DID()),
+		)
+		require.NoError(t, err)
+
+		rcpt, err := c.UploadList(context.Background(), uploadlist.Caveat{})
+		require.NoError(t, err)
+
+		ok, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+		require.Equal(t, fixtures.Alice.DID().String(), *ok.Cursor)
+	})
+
+	t.Run("space option", func(t *testing.T) {
+		c, err := client.NewClient(fixtures.Bob, client.WithConnection(conn))
+		require.NoError(t, err)
+		c.AddProofs(proofDlg)
+
+		_, err = c.UploadList(context.Background(), uploadlist.Caveat{})
+		require.Error(t, err)
+
+		rcpt, err := c.UploadList(context.Background(), uploadlist.Caveat{}, client.WithSpace(fixtures.Alice.DID()))
+		require.NoError(t, err)
+
+		ok, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+		require.Equal(t, fixtures.Alice.DID().String(), *ok.Cursor)
+	})
+
+	t.Run("proof option", func(t *testing.T) {
+		// the client proof is for another space, the proof passed to the
+		// method must be attached in addition to it
+		other := helpers.Must(delegation.Delegate(
+			fixtures.Mallory,
+			fixtures.Bob,
+			[]ucan.Capability[ucan.NoCaveats]{
+				ucan.NewCapability("upload/*", fixtures.Mallory.DID().String(), ucan.NoCaveats{}),
+			},
+		))
+		c, err := client.NewClient(
+			fixtures.Bob,
+			client.WithConnection(conn),
+			client.WithProofs([]delegation.Delegation{other}),
+		)
+		require.NoError(t, err)
+
+		for _, space := range []did.DID{fixtures.Alice.DID(), fixtures.Mallory.DID()} {
+			rcpt, err := c.UploadList(context.Background(), uploadlist.Caveat{}, client.WithSpace(space), client.WithProof(proofDlg))
+			require.NoError(t, err)
+
+			ok, x := result.Unwrap(rcpt.Out())
+			require.Nil(t, x)
+			require.Equal(t, space.String(), *ok.Cursor)
+		}
+		require.Len(t, c.Proofs(), 1)
+	})
+
+	t.Run("proof store", func(t *testing.T) {
+		// a delegation for a different space is not selected
+		other := helpers.Must(delegation.Delegate(
+			fixtures.Mallory,
+			fixtures.Bob,
+			[]ucan.Capability[ucan.NoCaveats]{
+				ucan.NewCapability("upload/*", fixtures.Mallory.DID().String(), ucan.NoCaveats{}),
+			},
+		))
+
+		c, err := client.NewClient(
+			fixtures.Bob,
+			client.WithConnection(conn),
+			client.WithProofStore(proof.NewMemoryStore(other, proofDlg)),
+			client.WithSpace(fixtures.Alice.DID()),
+		)
+		require.NoError(t, err)
+
+		rcpt, err := c.UploadList(context.Background(), uploadlist.Caveat{})
+		require.NoError(t, err)
+
+		ok, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+		require.Equal(t, fixtures.Alice.DID().String(), *ok.Cursor)
+	})
+
+	t.Run("missing proofs", func(t *testing.T) {
+		c, err := client.NewClient(
+			fixtures.Bob,
+			client.WithConnection(conn),
+			client.WithSpace(fixtures.Alice.DID()),
+		)
+		require.NoError(t, err)
+
+		rcpt, err := c.UploadList(context.Background(), uploadlist.Caveat{})
+		require.NoError(t, err)
+
+		_, x := result.Unwrap(rcpt.Out())
+		require.NotNil(t, x)
+	})
+}
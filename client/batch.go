@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/storeadd"
+	"github.com/storacha/go-w3up/proof"
+)
+
+// NewInvocation creates an invocation of the capability addressed to the
+// service, configured with the passed options e.g. proofs and expiration. Use
+// it to create invocations to send with `ExecuteBatch`.
+//
+// If a proof store is configured with `WithProofStore`, proofs for the
+// capability are selected from it and attached to the invocation.
+func NewInvocation[C ucan.CaveatBuilder](issuer principal.Signer, capability ucan.Capability[C], options ...Option) (invocation.Invocation, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.prfs != nil {
+		selected, err := proof.Select(cfg.prfs, issuer.DID(), capability.Can(), capability.With())
+		if err != nil {
+			return nil, fmt.Errorf("selecting proofs: %s", err)
+		}
+		cfg.prf = appendProofs(cfg.prf, selected)
+	}
+	return invocation.Invoke(
+		issuer,
+		cfg.conn.ID(),
+		capability,
+		convertToInvocationOptions(cfg)...,
+	)
+}
+
+// appendProofs appends the delegations to the proofs, skipping any that are
+// already present.
+func appendProofs(proofs []delegation.Delegation, dlgs []delegation.Delegation) []delegation.Delegation {
+	seen := map[string]struct{}{}
+	merged := make([]delegation.Delegation, 0, len(proofs)+len(dlgs))
+	for _, d := range slices.Concat(proofs, dlgs) {
+		if _, ok := seen[d.Link().String()]; ok {
+			continue
+		}
+		seen[d.Link().String()] = struct{}{}
+		merged = append(merged, d)
+	}
+	return merged
+}
+
+// ExecuteBatch sends multiple invocations to the service in a single request.
+// The response maps each invocation CID to its receipt. Use `ReadReceipt` to
+// read a typed receipt for an invocation from the response.
+func ExecuteBatch(ctx context.Context, invocations []invocation.Invocation, options ...Option) (client.ExecutionResponse, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	return execute(ctx, invocations, cfg.conn)
+}
+
+// ReadReceipt reads the receipt for the invocation from an execution response
+// using the passed typed receipt reader e.g. `storeadd.NewReceiptReader()`.
+func ReadReceipt[O, X any](resp client.ExecutionResponse, inv ucan.Link, reader receipt.ReceiptReader[O, X]) (receipt.Receipt[O, X], error) {
+	rcptlnk, ok := resp.Get(inv)
+	if !ok {
+		return nil, fmt.Errorf("receipt not found: %s", inv)
+	}
+	return reader.Read(rcptlnk, resp.Blocks())
+}
+
+// StoreAddBatch stores multiple DAGs encoded as CAR files in a single request
+// to the service. The receipts are returned in the same order as the params.
+//
+// Required delegated capability proofs: `store/add`
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocations.
+//
+// The `space` is the resource the invocations apply to. It is typically the
+// DID of a space.
+//
+// The `params` are caveats for each `store/add` invocation.
+func StoreAddBatch(ctx context.Context, issuer principal.Signer, space did.DID, params []storeadd.Caveat, options ...Option) ([]receipt.Receipt[*storeadd.Success, *storeadd.Failure], error) {
+	invs := make([]invocation.Invocation, 0, len(params))
+	for _, nb := range params {
+		inv, err := NewInvocation(issuer, storeadd.NewCapability(space, nb), options...)
+		if err != nil {
+			return nil, err
+		}
+		invs = append(invs, inv)
+	}
+
+	resp, err := ExecuteBatch(ctx, invs, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := storeadd.NewReceiptReader()
+	if err != nil {
+		return nil, err
+	}
+
+	rcpts := make([]receipt.Receipt[*storeadd.Success, *storeadd.Failure], 0, len(invs))
+	for _, inv := range invs {
+		rcpt, err := ReadReceipt(resp, inv.Link(), reader)
+		if err != nil {
+			return nil, err
+		}
+		rcpts = append(rcpts, rcpt)
+	}
+	return rcpts, nil
+}
@@ -0,0 +1,82 @@
+package client_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	ucanto "github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/transport"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/storeadd"
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+// countingChannel counts the requests sent over a channel.
+type countingChannel struct {
+	transport.Channel
+	requests atomic.Int64
+}
+
+func (c *countingChannel) Request(req transport.HTTPRequest) (transport.HTTPResponse, error) {
+	c.requests.Add(1)
+	return c.Channel.Request(req)
+}
+
+type storeAddSuccess struct {
+	link ipld.Link
+}
+
+func (ok storeAddSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 4, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "status", qp.String("done"))
+		qp.MapEntry(ma, "allocated", qp.Int(0))
+		qp.MapEntry(ma, "with", qp.String(fixtures.Alice.DID().String()))
+		qp.MapEntry(ma, "link", qp.Link(ok.link))
+	})
+}
+
+func TestStoreAddBatch(t *testing.T) {
+	conn := newTestConnection(t, provide(storeadd.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (storeAddSuccess, fx.Effects, error) {
+		n, err := cap.Nb().LookupByString("link")
+		if err != nil {
+			return storeAddSuccess{}, nil, err
+		}
+		link, err := n.AsLink()
+		return storeAddSuccess{link}, nil, err
+	}))
+	channel := &countingChannel{Channel: conn.Channel()}
+	conn = helpers.Must(ucanto.NewConnection(conn.ID(), channel))
+
+	var params []storeadd.Caveat
+	for range 3 {
+		params = append(params, storeadd.Caveat{Link: helpers.RandomCID(), Size: 128})
+	}
+
+	rcpts, err := client.StoreAddBatch(
+		context.Background(),
+		fixtures.Alice,
+		fixtures.Alice.DID(),
+		params,
+		client.WithConnection(conn),
+	)
+	require.NoError(t, err)
+	require.Len(t, rcpts, len(params))
+	require.Equal(t, int64(1), channel.requests.Load())
+
+	for i, rcpt := range rcpts {
+		ok, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+		require.Equal(t, params[i].Link, ok.Link)
+	}
+}
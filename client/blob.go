@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/invocation/ran"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/capability/blobaccept"
+	"github.com/storacha/go-w3up/capability/blobadd"
+	"github.com/storacha/go-w3up/capability/bloballocate"
+	"github.com/storacha/go-w3up/capability/httpput"
+	"github.com/storacha/go-w3up/capability/ucanconclude"
+)
+
+// BlobAddEffects are the tasks forked by the service when it handles a
+// `space/blob/add` invocation.
+type BlobAddEffects struct {
+	// Allocate is the `blob/allocate` task.
+	Allocate invocation.Invocation
+	// AllocateReceipt is the receipt for the `blob/allocate` task. It is nil if
+	// the service did not conclude the task in the response.
+	AllocateReceipt receipt.Receipt[*bloballocate.Success, *bloballocate.Failure]
+	// Put is the `http/put` task. It is executed by the client, which uploads
+	// the blob and then concludes the task with `ConcludeHTTPPut`.
+	Put invocation.Invocation
+	// PutReceipt is the receipt for the `http/put` task. It is nil if the task
+	// has not yet been concluded.
+	PutReceipt receipt.Receipt[*httpput.Success, *httpput.Failure]
+	// Accept is the `blob/accept` task.
+	Accept invocation.Invocation
+	// AcceptReceipt is the receipt for the `blob/accept` task. It is nil if the
+	// service did not conclude the task in the response.
+	AcceptReceipt receipt.Receipt[*blobaccept.Success, *blobaccept.Failure]
+}
+
+// ReadBlobAddEffects extracts the `blob/allocate`, `http/put` and
+// `blob/accept` tasks from the effects of a `space/blob/add` receipt, along
+// with any receipts for those tasks the service concluded in the response.
+//
+// The receipt must have been read from the blocks of the execution response
+// (as returned by `BlobAdd`) so that the effect invocations are available.
+func ReadBlobAddEffects(rcpt receipt.Receipt[*blobadd.Success, *blobadd.Failure]) (BlobAddEffects, error) {
+	effects := BlobAddEffects{}
+
+	fx := rcpt.Fx()
+	// clone the forks so appending the join does not write to the receipt
+	tasks := slices.Clone(fx.Fork())
+	if fx.Join().Link() != nil {
+		tasks = append(tasks, fx.Join())
+	}
+
+	for _, task := range tasks {
+		inv, ok := task.Invocation()
+		if !ok {
+			continue
+		}
+		caps := inv.Capabilities()
+		if len(caps) == 0 {
+			continue
+		}
+		switch caps[0].Can() {
+		case bloballocate.Ability:
+			effects.Allocate = inv
+		case httpput.Ability:
+			effects.Put = inv
+		case blobaccept.Ability:
+			effects.Accept = inv
+		}
+	}
+
+	if effects.Allocate == nil {
+		return effects, fmt.Errorf("missing %s effect", bloballocate.Ability)
+	}
+	if effects.Put == nil {
+		return effects, fmt.Errorf("missing %s effect", httpput.Ability)
+	}
+	if effects.Accept == nil {
+		return effects, fmt.Errorf("missing %s effect", blobaccept.Ability)
+	}
+
+	concluded, err := concludedReceipts(fx)
+	if err != nil {
+		return effects, err
+	}
+
+	if c, ok := concluded[effects.Allocate.Link().String()]; ok {
+		reader, err := bloballocate.NewReceiptReader()
+		if err != nil {
+			return effects, err
+		}
+		effects.AllocateReceipt, err = reader.Read(c.rcpt, c.blks.Iterator())
+		if err != nil {
+			return effects, fmt.Errorf("reading %s receipt: %s", bloballocate.Ability, err)
+		}
+	}
+
+	if c, ok := concluded[effects.Put.Link().String()]; ok {
+		reader, err := httpput.NewReceiptReader()
+		if err != nil {
+			return effects, err
+		}
+		effects.PutReceipt, err = reader.Read(c.rcpt, c.blks.Iterator())
+		if err != nil {
+			return effects, fmt.Errorf("reading %s receipt: %s", httpput.Ability, err)
+		}
+	}
+
+	if c, ok := concluded[effects.Accept.Link().String()]; ok {
+		reader, err := blobaccept.NewReceiptReader()
+		if err != nil {
+			return effects, err
+		}
+		effects.AcceptReceipt, err = reader.Read(c.rcpt, c.blks.Iterator())
+		if err != nil {
+			return effects, fmt.Errorf("reading %s receipt: %s", blobaccept.Ability, err)
+		}
+	}
+
+	return effects, nil
+}
+
+// PutBlob uploads the blob bytes to the address allocated by the service in
+// the `blob/allocate` receipt. The upload is aborted if the context is
+// canceled.
+func PutBlob(ctx context.Context, address bloballocate.Address, body io.Reader, size uint64) error {
+	hr, err := http.NewRequestWithContext(ctx, "PUT", address.Url, body)
+	if err != nil {
+		return fmt.Errorf("creating HTTP request: %s", err)
+	}
+
+	hdr := map[string][]string{}
+	for k, v := range address.Headers.Values {
+		if k == "content-length" {
+			continue
+		}
+		hdr[k] = []string{v}
+	}
+
+	hr.Header = hdr
+	hr.ContentLength = int64(size)
+	httpClient := http.Client{}
+	res, err := httpClient.Do(hr)
+	if err != nil {
+		return fmt.Errorf("doing HTTP request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("non-2xx status code while uploading blob: %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// ConcludeHTTPPut signals to the service that the blob has been uploaded. It
+// issues a receipt for the `http/put` task, signed by the key the service
+// included in the task facts, and sends it to the service in a `ucan/conclude`
+// invocation.
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation.
+//
+// The `put` is the `http/put` task from the `space/blob/add` receipt effects.
+func ConcludeHTTPPut(ctx context.Context, issuer principal.Signer, put invocation.Invocation, options ...Option) (receipt.Receipt[*ucanconclude.Success, *ucanconclude.Failure], error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	putSigner, err := httpput.ExtractSigner(put.Facts())
+	if err != nil {
+		return nil, fmt.Errorf("extracting %s signer: %s", httpput.Ability, err)
+	}
+
+	// The task is a view over all the blocks in the response it was read from,
+	// so include only its root block in the receipt.
+	taskbs, err := blockstore.NewBlockReader(blockstore.WithBlocks([]ipld.Block{put.Root()}))
+	if err != nil {
+		return nil, fmt.Errorf("creating block reader: %s", err)
+	}
+	task, err := invocation.NewInvocation(put.Root(), taskbs)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s task: %s", httpput.Ability, err)
+	}
+
+	putrcpt, err := receipt.Issue(
+		putSigner,
+		result.Ok[httpput.Success, ipld.Builder](httpput.Success{}),
+		ran.FromInvocation(task),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("issuing %s receipt: %s", httpput.Ability, err)
+	}
+
+	inv, err := invocation.Invoke(
+		issuer,
+		cfg.conn.ID(),
+		ucanconclude.NewCapability(issuer.DID(), ucanconclude.Caveat{Receipt: putrcpt.Root().Link()}),
+		convertToInvocationOptions(cfg)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for blk, err := range putrcpt.Blocks() {
+		if err != nil {
+			return nil, fmt.Errorf("reading %s receipt blocks: %s", httpput.Ability, err)
+		}
+		if err := inv.Attach(blk); err != nil {
+			return nil, fmt.Errorf("attaching %s receipt block: %s", httpput.Ability, err)
+		}
+	}
+
+	reader, err := ucanconclude.NewReceiptReader()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := execute(ctx, []invocation.Invocation{inv}, cfg.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadReceipt(resp, inv.Link(), reader)
+}
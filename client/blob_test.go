@@ -0,0 +1,253 @@
+package client_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/invocation/ran"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/blobaccept"
+	"github.com/storacha/go-w3up/capability/blobadd"
+	"github.com/storacha/go-w3up/capability/bloballocate"
+	"github.com/storacha/go-w3up/capability/httpput"
+	"github.com/storacha/go-w3up/capability/ucanconclude"
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+type keysFact struct {
+	signer principal.Signer
+}
+
+func (f keysFact) ToIPLD() (map[string]datamodel.Node, error) {
+	id := f.signer.DID().String()
+	n, err := qp.BuildMap(basicnode.Prototype.Any, 2, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "id", qp.String(id))
+		qp.MapEntry(ma, "keys", qp.Map(1, func(ma datamodel.MapAssembler) {
+			qp.MapEntry(ma, id, qp.Bytes(f.signer.Encode()))
+		}))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]datamodel.Node{"keys": n}, nil
+}
+
+type allocateSuccess struct {
+	size    int64
+	address string
+}
+
+func (ok allocateSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 2, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "size", qp.Int(ok.size))
+		qp.MapEntry(ma, "address", qp.Map(3, func(ma datamodel.MapAssembler) {
+			qp.MapEntry(ma, "url", qp.String(ok.address))
+			qp.MapEntry(ma, "headers", qp.Map(0, func(ma datamodel.MapAssembler) {}))
+			qp.MapEntry(ma, "expires", qp.Int(0))
+		}))
+	})
+}
+
+type siteSuccess struct {
+	accept ipld.Link
+}
+
+func (ok siteSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "site", qp.Map(1, func(ma datamodel.MapAssembler) {
+			qp.MapEntry(ma, "ucan/await", qp.List(2, func(la datamodel.ListAssembler) {
+				qp.ListEntry(la, qp.String(".out.ok.site"))
+				qp.ListEntry(la, qp.Link(ok.accept))
+			}))
+		}))
+	})
+}
+
+// blobAddReceipt issues a `space/blob/add` receipt in the shape the service
+// produces, with the `blob/allocate` task concluded.
+func blobAddReceipt(t *testing.T, data []byte) (receipt.Receipt[*blobadd.Success, *blobadd.Failure], principal.Signer) {
+	t.Helper()
+
+	space := fixtures.Alice
+	digest := helpers.Must(multihash.Sum(data, multihash.SHA2_256, -1))
+	blob := blobadd.Blob{Digest: digest, Size: uint64(len(data))}
+
+	add := helpers.Must(invocation.Invoke(
+		fixtures.Alice,
+		fixtures.Service,
+		blobadd.NewCapability(space.DID(), blobadd.Caveat{Blob: blob}),
+	))
+
+	allocate := helpers.Must(invocation.Invoke(
+		fixtures.Service,
+		fixtures.Service,
+		bloballocate.NewCapability(fixtures.Service.DID(), bloballocate.Caveat{
+			Space: space.DID().String(),
+			Blob:  bloballocate.Blob(blob),
+			Cause: add.Link(),
+		}),
+	))
+
+	putSigner := helpers.Must(signer.Generate())
+	put := helpers.Must(invocation.Invoke(
+		putSigner,
+		putSigner,
+		httpput.NewCapability(putSigner, httpput.Caveat{
+			Body:    httpput.Body(blob),
+			Url:     httpput.Promise{UcanAwait: httpput.Await{Selector: ".out.ok.address.url", Link: allocate.Link()}},
+			Headers: httpput.Promise{UcanAwait: httpput.Await{Selector: ".out.ok.address.headers", Link: allocate.Link()}},
+		}),
+		delegation.WithFacts([]ucan.FactBuilder{keysFact{putSigner}}),
+	))
+
+	accept := helpers.Must(invocation.Invoke(
+		fixtures.Service,
+		fixtures.Service,
+		blobaccept.NewCapability(fixtures.Service.DID(), blobaccept.Caveat{
+			Space: space.DID().String(),
+			Blob:  blobaccept.Blob(blob),
+			Put:   blobaccept.Promise{UcanAwait: blobaccept.Await{Selector: ".out.ok", Link: put.Link()}},
+		}),
+	))
+
+	allocrcpt := helpers.Must(receipt.Issue(
+		fixtures.Service,
+		result.Ok[allocateSuccess, ipld.Builder](allocateSuccess{size: int64(len(data)), address: "https://example.org/blob"}),
+		ran.FromInvocation(allocate),
+	))
+
+	conclude := helpers.Must(invocation.Invoke(
+		fixtures.Service,
+		fixtures.Service,
+		ucanconclude.NewCapability(fixtures.Service.DID(), ucanconclude.Caveat{Receipt: allocrcpt.Root().Link()}),
+	))
+	for blk, err := range allocrcpt.Blocks() {
+		require.NoError(t, err)
+		require.NoError(t, conclude.Attach(blk))
+	}
+
+	rcpt := helpers.Must(receipt.Issue(
+		fixtures.Service,
+		result.Ok[siteSuccess, ipld.Builder](siteSuccess{accept.Link()}),
+		ran.FromInvocation(add),
+		receipt.WithFork(
+			fx.FromInvocation(allocate),
+			fx.FromInvocation(conclude),
+			fx.FromInvocation(put),
+			fx.FromInvocation(accept),
+		),
+		receipt.WithJoin(fx.FromInvocation(accept)),
+	))
+
+	// simulate the execution response, which contains the receipt and all of
+	// the effect blocks
+	bs := helpers.Must(blockstore.NewBlockStore())
+	for _, view := range []ipld.View{rcpt, allocate, conclude, put, accept} {
+		require.NoError(t, blockstore.WriteInto(view, bs))
+	}
+
+	reader := helpers.Must(blobadd.NewReceiptReader())
+	return helpers.Must(reader.Read(rcpt.Root().Link(), bs.Iterator())), putSigner
+}
+
+func TestReadBlobAddEffects(t *testing.T) {
+	data := helpers.RandomBytes(128)
+	rcpt, putSigner := blobAddReceipt(t, data)
+
+	ok, x := result.Unwrap(rcpt.Out())
+	require.Nil(t, x)
+	require.Equal(t, ".out.ok.site", ok.Site.UcanAwait.Selector)
+
+	effects, err := client.ReadBlobAddEffects(rcpt)
+	require.NoError(t, err)
+
+	require.Equal(t, bloballocate.Ability, effects.Allocate.Capabilities()[0].Can())
+	require.Equal(t, httpput.Ability, effects.Put.Capabilities()[0].Can())
+	require.Equal(t, blobaccept.Ability, effects.Accept.Capabilities()[0].Can())
+	require.Equal(t, ok.Site.UcanAwait.Link, effects.Accept.Link())
+	require.Nil(t, effects.PutReceipt)
+	require.Nil(t, effects.AcceptReceipt)
+
+	require.NotNil(t, effects.AllocateReceipt)
+	alloc, allocx := result.Unwrap(effects.AllocateReceipt.Out())
+	require.Nil(t, allocx)
+	require.Equal(t, uint64(len(data)), alloc.Size)
+	require.Equal(t, "https://example.org/blob", alloc.Address.Url)
+
+	s, err := httpput.ExtractSigner(effects.Put.Facts())
+	require.NoError(t, err)
+	require.Equal(t, putSigner.DID(), s.DID())
+}
+
+// spareForks is a receipt whose forks have spare capacity, so appending to
+// them writes to the backing array shared with the receipt.
+type spareForks struct {
+	receipt.Receipt[*blobadd.Success, *blobadd.Failure]
+	forks []fx.Effect
+}
+
+func (r spareForks) Fx() fx.Effects {
+	return fx.NewEffects(fx.WithFork(r.forks...), fx.WithJoin(r.Receipt.Fx().Join()))
+}
+
+func TestReadBlobAddEffectsKeepsReceipt(t *testing.T) {
+	rcpt, _ := blobAddReceipt(t, helpers.RandomBytes(128))
+	forks := rcpt.Fx().Fork()
+	spare := spareForks{rcpt, append(make([]fx.Effect, 0, len(forks)+1), forks...)}
+
+	_, err := client.ReadBlobAddEffects(spare)
+	require.NoError(t, err)
+	require.Zero(t, spare.forks[:len(forks)+1][len(forks)])
+}
+
+type acceptSuccess struct {
+	site ipld.Link
+}
+
+func (ok acceptSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "site", qp.Link(ok.site))
+	})
+}
+
+func TestPollBlobAcceptReceipt(t *testing.T) {
+	// the default service must not be used
+	t.Setenv("W3UP_SERVICE_URL", "http://127.0.0.1:1")
+
+	accept := task(t, blobaccept.Ability)
+	site := task(t, "assert/location")
+	endpoint, _ := receiptsServer(t, 1, helpers.Must(receipt.Issue(
+		fixtures.Service,
+		result.Ok[acceptSuccess, ipld.Builder](acceptSuccess{site.Link()}),
+		ran.FromInvocation(accept),
+	)))
+	serviceURL := helpers.Must(url.Parse(strings.TrimSuffix(endpoint.String(), "receipt/")))
+	conn := helpers.Must(client.NewConnection(serviceURL, fixtures.Service.DID()))
+	c := helpers.Must(client.NewClient(fixtures.Alice, client.WithConnection(conn)))
+
+	rcpt, err := c.PollBlobAcceptReceipt(context.Background(), accept.Link(), client.WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+
+	ok, x := result.Unwrap(rcpt.Out())
+	require.Nil(t, x)
+	require.Equal(t, site.Link(), ok.Site)
+}
@@ -1,13 +1,14 @@
 package client
 
 import (
-	"fmt"
+	"context"
 
-	"github.com/storacha/go-ucanto/client"
-	"github.com/storacha/go-ucanto/core/invocation"
 	"github.com/storacha/go-ucanto/core/receipt"
 	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/capability/blobadd"
+	"github.com/storacha/go-w3up/capability/provideradd"
+	"github.com/storacha/go-w3up/capability/spaceinfo"
 	"github.com/storacha/go-w3up/capability/storeadd"
 	"github.com/storacha/go-w3up/capability/uploadadd"
 	"github.com/storacha/go-w3up/capability/uploadlist"
@@ -18,46 +19,48 @@ import (
 //
 // Required delegated capability proofs: `store/add`
 //
+// The `ctx` aborts the request to the service when canceled.
+//
 // The `issuer` is the signing authority that is issuing the UCAN invocation.
 //
 // The `space` is the resource the invocation applies to. It is typically the
 // DID of a space.
 //
 // The `params` are caveats required to perform a `store/add` invocation.
-func StoreAdd(issuer principal.Signer, space did.DID, params storeadd.Caveat, options ...Option) (receipt.Receipt[*storeadd.Success, *storeadd.Failure], error) {
-	cfg := ClientConfig{conn: DefaultConnection}
-	for _, opt := range options {
-		if err := opt(&cfg); err != nil {
-			return nil, err
-		}
-	}
-
-	inv, err := invocation.Invoke(
+func StoreAdd(ctx context.Context, issuer principal.Signer, space did.DID, params storeadd.Caveat, options ...Option) (receipt.Receipt[*storeadd.Success, *storeadd.Failure], error) {
+	return Invoke[storeadd.Caveat, *storeadd.Success, *storeadd.Failure](
+		ctx,
 		issuer,
-		cfg.conn.ID(),
 		storeadd.NewCapability(space, params),
-		convertToInvocationOptions(cfg)...,
+		storeadd.ResultSchema,
+		options...,
 	)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Execute([]invocation.Invocation{inv}, cfg.conn)
-	if err != nil {
-		return nil, err
-	}
-
-	rcptlnk, ok := resp.Get(inv.Link())
-	if !ok {
-		return nil, fmt.Errorf("receipt not found: %s", inv.Link())
-	}
-
-	reader, err := storeadd.NewReceiptReader()
-	if err != nil {
-		return nil, err
-	}
+}
 
-	return reader.Read(rcptlnk, resp.Blocks())
+// BlobAdd stores a blob with the service. The issuer needs proof of
+// `space/blob/add` delegated capability.
+//
+// Required delegated capability proofs: `space/blob/add`
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation.
+//
+// The `space` is the resource the invocation applies to. It is typically the
+// DID of a space.
+//
+// The `params` are caveats required to perform a `space/blob/add` invocation.
+//
+// The receipt effects describe the remaining steps of the upload. Use
+// `ReadBlobAddEffects` to obtain them.
+func BlobAdd(ctx context.Context, issuer principal.Signer, space did.DID, params blobadd.Caveat, options ...Option) (receipt.Receipt[*blobadd.Success, *blobadd.Failure], error) {
+	return Invoke[blobadd.Caveat, *blobadd.Success, *blobadd.Failure](
+		ctx,
+		issuer,
+		blobadd.NewCapability(space, params),
+		blobadd.ResultSchema,
+		options...,
+	)
 }
 
 // UploadAdd registers an "upload" with the service. The issuer needs proof of
@@ -65,90 +68,87 @@ func StoreAdd(issuer principal.Signer, space did.DID, params storeadd.Caveat, op
 //
 // Required delegated capability proofs: `upload/add`
 //
+// The `ctx` aborts the request to the service when canceled.
+//
 // The `issuer` is the signing authority that is issuing the UCAN invocation.
 //
 // The `space` is the resource the invocation applies to. It is typically the
 // DID of a space.
 //
 // The `params` are caveats required to perform an `upload/add` invocation.
-func UploadAdd(issuer principal.Signer, space did.DID, params uploadadd.Caveat, options ...Option) (receipt.Receipt[*uploadadd.Success, *uploadadd.Failure], error) {
-	cfg := ClientConfig{conn: DefaultConnection}
-	for _, opt := range options {
-		if err := opt(&cfg); err != nil {
-			return nil, err
-		}
-	}
-
-	inv, err := invocation.Invoke(
+func UploadAdd(ctx context.Context, issuer principal.Signer, space did.DID, params uploadadd.Caveat, options ...Option) (receipt.Receipt[*uploadadd.Success, *uploadadd.Failure], error) {
+	return Invoke[uploadadd.Caveat, *uploadadd.Success, *uploadadd.Failure](
+		ctx,
 		issuer,
-		cfg.conn.ID(),
 		uploadadd.NewCapability(space, params),
-		convertToInvocationOptions(cfg)...,
+		uploadadd.ResultSchema,
+		options...,
 	)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Execute([]invocation.Invocation{inv}, cfg.conn)
-	if err != nil {
-		return nil, err
-	}
-
-	rcptlnk, ok := resp.Get(inv.Link())
-	if !ok {
-		return nil, fmt.Errorf("receipt not found: %s", inv.Link())
-	}
-
-	reader, err := uploadadd.NewReceiptReader()
-	if err != nil {
-		return nil, err
-	}
-
-	return reader.Read(rcptlnk, resp.Blocks())
 }
 
 // UploadList returns a paginated list of uploads in a space.
 //
 // Required delegated capability proofs: `upload/list`
 //
+// The `ctx` aborts the request to the service when canceled.
+//
 // The `issuer` is the signing authority that is issuing the UCAN invocation.
 //
 // The `space` is the resource the invocation applies to. It is typically the
 // DID of a space.
 //
 // The `params` are caveats required to perform an `upload/list` invocation.
-func UploadList(issuer principal.Signer, space did.DID, params uploadlist.Caveat, options ...Option) (receipt.Receipt[*uploadlist.Success, *uploadlist.Failure], error) {
-	cfg := ClientConfig{conn: DefaultConnection}
-	for _, opt := range options {
-		if err := opt(&cfg); err != nil {
-			return nil, err
-		}
-	}
-
-	inv, err := invocation.Invoke(
+func UploadList(ctx context.Context, issuer principal.Signer, space did.DID, params uploadlist.Caveat, options ...Option) (receipt.Receipt[*uploadlist.Success, *uploadlist.Failure], error) {
+	return Invoke[uploadlist.Caveat, *uploadlist.Success, *uploadlist.Failure](
+		ctx,
 		issuer,
-		cfg.conn.ID(),
 		uploadlist.NewCapability(space, params),
-		convertToInvocationOptions(cfg)...,
+		uploadlist.ResultSchema,
+		options...,
 	)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Execute([]invocation.Invocation{inv}, cfg.conn)
-	if err != nil {
-		return nil, err
-	}
-
-	rcptlnk, ok := resp.Get(inv.Link())
-	if !ok {
-		return nil, fmt.Errorf("receipt not found: %s", inv.Link())
-	}
+}
 
-	reader, err := uploadlist.NewReceiptReader()
-	if err != nil {
-		return nil, err
-	}
+// SpaceInfo returns information about a space, including the storage
+// providers it is provisioned with.
+//
+// Required delegated capability proofs: `space/info`
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation.
+//
+// The `space` is the resource the invocation applies to. It is the DID of a
+// space.
+func SpaceInfo(ctx context.Context, issuer principal.Signer, space did.DID, options ...Option) (receipt.Receipt[*spaceinfo.Success, *spaceinfo.Failure], error) {
+	return Invoke[spaceinfo.Caveat, *spaceinfo.Success, *spaceinfo.Failure](
+		ctx,
+		issuer,
+		spaceinfo.NewCapability(space, spaceinfo.Caveat{}),
+		spaceinfo.ResultSchema,
+		options...,
+	)
+}
 
-	return reader.Read(rcptlnk, resp.Blocks())
+// ProviderAdd provisions a space with a storage provider, so that it can be
+// used to store data. The storage used by the space is billed to the account.
+//
+// Required delegated capability proofs: `provider/add`
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation.
+//
+// The `account` is the resource the invocation applies to. It is the DID of
+// the account (e.g. `did:mailto:`) that the issuer has logged in to.
+//
+// The `params` are caveats required to perform a `provider/add` invocation.
+// The `Consumer` is the DID of the space to provision.
+func ProviderAdd(ctx context.Context, issuer principal.Signer, account did.DID, params provideradd.Caveat, options ...Option) (receipt.Receipt[*provideradd.Success, *provideradd.Failure], error) {
+	return Invoke[provideradd.Caveat, *provideradd.Success, *provideradd.Failure](
+		ctx,
+		issuer,
+		provideradd.NewCapability(account, params),
+		provideradd.ResultSchema,
+		options...,
+	)
 }
@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/spaceinfo"
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+type spaceInfoSuccess struct {
+	space string
+}
+
+func (ok spaceInfoSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 2, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "did", qp.String(ok.space))
+		qp.MapEntry(ma, "providers", qp.List(1, func(la datamodel.ListAssembler) {
+			qp.ListEntry(la, qp.String(fixtures.Service.DID().String()))
+		}))
+	})
+}
+
+func TestSpaceInfo(t *testing.T) {
+	conn := newTestConnection(t, provide(spaceinfo.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (spaceInfoSuccess, fx.Effects, error) {
+		return spaceInfoSuccess{cap.With()}, nil, nil
+	}))
+
+	rcpt, err := client.SpaceInfo(
+		context.Background(),
+		fixtures.Alice,
+		fixtures.Alice.DID(),
+		client.WithConnection(conn),
+	)
+	require.NoError(t, err)
+
+	ok, x := result.Unwrap(rcpt.Out())
+	require.Nil(t, x)
+	require.Equal(t, fixtures.Alice.DID().String(), ok.Did)
+	require.Equal(t, []string{fixtures.Service.DID().String()}, ok.Providers)
+}
@@ -1,37 +1,76 @@
 package client
 
 import (
-	"log"
+	"fmt"
 	"net/url"
+	"os"
+	"sync"
 
 	"github.com/storacha/go-ucanto/client"
 	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/transport/car"
-	"github.com/storacha/go-ucanto/transport/http"
 )
 
-var DefaultConnection client.Connection
+const (
+	// DefaultServiceURL is the URL of the service used when `W3UP_SERVICE_URL`
+	// is not set.
+	DefaultServiceURL = "https://up.web3.storage"
+	// DefaultServiceDID is the DID of the service used when `W3UP_SERVICE_DID`
+	// is not set.
+	DefaultServiceDID = "did:web:web3.storage"
+)
+
+// NewConnection creates a connection to the service at the passed URL,
+// identified by the passed DID. Requests are sent over HTTP with CAR encoding
+// unless a different codec is configured in the options.
+func NewConnection(serviceURL *url.URL, serviceDID did.DID, options ...client.Option) (client.Connection, error) {
+	opts := append([]client.Option{client.WithOutboundCodec(car.NewCAROutboundCodec())}, options...)
+	return client.NewConnection(serviceDID, NewHTTPChannel(serviceURL), opts...)
+}
 
-func init() {
-	// service URL & DID
-	serviceURL, err := url.Parse("https://up.web3.storage")
+var defaultConnection = sync.OnceValues(func() (client.Connection, error) {
+	serviceURL, err := ServiceURL()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-
-	servicePrincipal, err := did.Parse("did:web:web3.storage")
+	serviceDID, err := ServiceDID()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	return NewConnection(serviceURL, serviceDID)
+})
 
-	// HTTP transport and CAR encoding
-	channel := http.NewHTTPChannel(serviceURL)
-	codec := car.NewCAROutboundCodec()
+// DefaultConnection returns the connection used when none is configured with
+// `WithConnection`. It is created on first use from `ServiceURL` and
+// `ServiceDID`.
+func DefaultConnection() (client.Connection, error) {
+	return defaultConnection()
+}
 
-	conn, err := client.NewConnection(servicePrincipal, channel, client.WithOutboundCodec(codec))
+// ServiceURL returns the URL of the service from the `W3UP_SERVICE_URL`
+// environment variable, or `DefaultServiceURL` if it is not set.
+func ServiceURL() (*url.URL, error) {
+	str := os.Getenv("W3UP_SERVICE_URL")
+	if str == "" {
+		str = DefaultServiceURL
+	}
+	u, err := url.Parse(str)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("parsing service URL: %s", err)
 	}
+	return u, nil
+}
 
-	DefaultConnection = conn
+// ServiceDID returns the DID of the service from the `W3UP_SERVICE_DID`
+// environment variable, or `DefaultServiceDID` if it is not set.
+func ServiceDID() (did.DID, error) {
+	str := os.Getenv("W3UP_SERVICE_DID")
+	if str == "" {
+		str = DefaultServiceDID
+	}
+	d, err := did.Parse(str)
+	if err != nil {
+		return did.DID{}, fmt.Errorf("parsing service DID: %s", err)
+	}
+	return d, nil
 }
@@ -0,0 +1,50 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceFromEnv(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("W3UP_SERVICE_URL", "")
+		t.Setenv("W3UP_SERVICE_DID", "")
+
+		u, err := client.ServiceURL()
+		require.NoError(t, err)
+		require.Equal(t, client.DefaultServiceURL, u.String())
+
+		d, err := client.ServiceDID()
+		require.NoError(t, err)
+		require.Equal(t, client.DefaultServiceDID, d.String())
+
+		r, err := client.DefaultReceiptsEndpoint()
+		require.NoError(t, err)
+		require.Equal(t, "https://up.web3.storage/receipt/", r.String())
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Setenv("W3UP_SERVICE_URL", "http://localhost:8080")
+		t.Setenv("W3UP_SERVICE_DID", "did:web:staging.web3.storage")
+
+		u, err := client.ServiceURL()
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost:8080", u.String())
+
+		d, err := client.ServiceDID()
+		require.NoError(t, err)
+		require.Equal(t, "did:web:staging.web3.storage", d.String())
+
+		r, err := client.DefaultReceiptsEndpoint()
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost:8080/receipt/", r.String())
+	})
+
+	t.Run("invalid DID", func(t *testing.T) {
+		t.Setenv("W3UP_SERVICE_DID", "not a DID")
+		_, err := client.ServiceDID()
+		require.Error(t, err)
+	})
+}
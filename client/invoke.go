@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Invoke invokes a capability on the service and returns the receipt, with the
+// result read using the passed IPLD schema. It can be used to invoke
+// capabilities that do not have a dedicated function in this package.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation.
+//
+// The `capability` is the capability to invoke e.g.
+// `uploadlist.NewCapability(space, uploadlist.Caveat{})`.
+//
+// The `resultSchema` is an IPLD schema defining a `Result` union of the
+// success (`O`) and failure (`X`) types e.g. `uploadlist.ResultSchema`.
+func Invoke[C ucan.CaveatBuilder, O, X any](ctx context.Context, issuer principal.Signer, capability ucan.Capability[C], resultSchema []byte, options ...Option) (receipt.Receipt[O, X], error) {
+	reader, err := receipt.NewReceiptReader[O, X](resultSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := NewInvocation(issuer, capability, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ExecuteBatch(ctx, []invocation.Invocation{inv}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadReceipt(resp, inv.Link(), reader)
+}
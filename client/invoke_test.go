@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+type echoCaveat struct {
+	message string
+}
+
+func (c echoCaveat) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "message", qp.String(c.message))
+	})
+}
+
+type echoSuccess struct {
+	Message string
+}
+
+type echoFailure struct {
+	Message string
+}
+
+var echoResultSchema = []byte(`
+type Result union {
+  | Success "ok"
+  | Failure "error"
+} representation keyed
+
+type Success struct {
+  message String
+}
+
+type Failure struct {
+  message String
+}
+`)
+
+func TestInvoke(t *testing.T) {
+	conn := newTestConnection(t, provide("test/echo", func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (echoCaveat, fx.Effects, error) {
+		msg, err := cap.Nb().LookupByString("message")
+		if err != nil {
+			return echoCaveat{}, nil, err
+		}
+		s, err := msg.AsString()
+		return echoCaveat{s}, nil, err
+	}))
+
+	rcpt, err := client.Invoke[echoCaveat, *echoSuccess, *echoFailure](
+		context.Background(),
+		fixtures.Alice,
+		ucan.NewCapability("test/echo", fixtures.Alice.DID().String(), echoCaveat{"hello"}),
+		echoResultSchema,
+		client.WithConnection(conn),
+	)
+	require.NoError(t, err)
+
+	ok, x := result.Unwrap(rcpt.Out())
+	require.Nil(t, x)
+	require.Equal(t, "hello", ok.Message)
+}
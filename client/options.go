@@ -1,9 +1,15 @@
 package client
 
 import (
+	"fmt"
+	"net/url"
+	"time"
+
 	"github.com/storacha/go-ucanto/client"
 	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/proof"
 )
 
 // Option is an option configuring a UCAN delegation.
@@ -16,6 +22,12 @@ type ClientConfig struct {
 	nnc  string
 	fct  []ucan.FactBuilder
 	prf  []delegation.Delegation
+	spc  did.DID
+	prfs proof.Store
+	// receipts polling
+	rcptsURL     *url.URL
+	pollInterval time.Duration
+	pollRetries  int
 }
 
 // WithConnection configures the connection to execute the invocation on.
@@ -82,6 +94,75 @@ func WithProofs(prf []delegation.Delegation) Option {
 	}
 }
 
+// WithProofStore configures a store of delegations that proofs for the
+// invocation are selected from. Delegations in the store that prove the issuer
+// may invoke the capability are attached in addition to any proofs configured
+// with `WithProof` or `WithProofs`.
+func WithProofStore(store proof.Store) Option {
+	return func(cfg *ClientConfig) error {
+		cfg.prfs = store
+		return nil
+	}
+}
+
+// WithSpace configures the space a `Client` invokes capabilities on. It
+// overrides the default space of the client when passed to a method.
+func WithSpace(space did.DID) Option {
+	return func(cfg *ClientConfig) error {
+		cfg.spc = space
+		return nil
+	}
+}
+
+// WithReceiptsEndpoint configures the URL receipts are fetched from. The task
+// CID is appended to the URL path.
+func WithReceiptsEndpoint(endpoint *url.URL) Option {
+	return func(cfg *ClientConfig) error {
+		cfg.rcptsURL = endpoint
+		return nil
+	}
+}
+
+// WithPollInterval configures the time to wait between attempts to fetch a
+// receipt that is not yet available.
+func WithPollInterval(interval time.Duration) Option {
+	return func(cfg *ClientConfig) error {
+		cfg.pollInterval = interval
+		return nil
+	}
+}
+
+// WithPollRetries configures the maximum number of attempts to fetch a receipt
+// that is not yet available. It must be at least 1.
+func WithPollRetries(retries int) Option {
+	return func(cfg *ClientConfig) error {
+		if retries < 1 {
+			return fmt.Errorf("poll retries must be at least 1: %d", retries)
+		}
+		cfg.pollRetries = retries
+		return nil
+	}
+}
+
+// newConfig applies the options to a new config. The connection defaults to
+// `DefaultConnection` if not configured.
+func newConfig(options []Option) (ClientConfig, error) {
+	cfg := ClientConfig{}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.conn == nil {
+		conn, err := DefaultConnection()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.conn = conn
+	}
+	return cfg, nil
+}
+
 func convertToInvocationOptions(cfg ClientConfig) []delegation.Option {
 	var opts []delegation.Option
 	if cfg.exp != nil {
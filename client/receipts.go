@@ -0,0 +1,403 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	rdm "github.com/storacha/go-ucanto/core/receipt/datamodel"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/transport/car/response"
+	uhttp "github.com/storacha/go-ucanto/transport/http"
+	"github.com/storacha/go-w3up/capability/ucanconclude"
+)
+
+// DefaultReceiptsEndpoint returns the receipts endpoint of the
+// `DefaultConnection`. It is the `/receipt/` path of `ServiceURL`.
+func DefaultReceiptsEndpoint() (*url.URL, error) {
+	serviceURL, err := ServiceURL()
+	if err != nil {
+		return nil, err
+	}
+	return serviceURL.JoinPath("receipt/"), nil
+}
+
+// ReceiptsEndpoint returns the receipts endpoint of the service a connection is
+// to, the `/receipt/` path of the service URL. It returns false if the
+// connection does not send requests over an HTTP channel created with
+// `NewHTTPChannel`, in which case the endpoint must be configured with
+// `WithReceiptsEndpoint`.
+func ReceiptsEndpoint(conn client.Connection) (*url.URL, bool) {
+	ch, ok := conn.Channel().(interface{ URL() *url.URL })
+	if !ok {
+		return nil, false
+	}
+	return ch.URL().JoinPath("receipt/"), true
+}
+
+const (
+	// DefaultPollInterval is the default time to wait between attempts to fetch
+	// a receipt.
+	DefaultPollInterval = time.Second
+	// DefaultPollRetries is the default maximum number of attempts to fetch a
+	// receipt.
+	DefaultPollRetries = 10
+)
+
+// ErrReceiptNotFound is returned when the service does not (yet) have a
+// receipt for a task.
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// EffectReceipts are the resolved receipts for the effects of a receipt.
+type EffectReceipts struct {
+	// Fork are the receipts for the fork effects, in order.
+	Fork []ReceiptTree
+	// Join is the receipt for the join effect. It is nil if there is no join
+	// effect.
+	Join *ReceiptTree
+}
+
+// ReceiptTree is a receipt along with the resolved receipts of its effects.
+type ReceiptTree struct {
+	Receipt receipt.AnyReceipt
+	Effects EffectReceipts
+}
+
+func newReceiptsConfig(options []Option) (ClientConfig, error) {
+	cfg := ClientConfig{
+		pollInterval: DefaultPollInterval,
+		pollRetries:  DefaultPollRetries,
+	}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.rcptsURL == nil {
+		conn := cfg.conn
+		if conn == nil {
+			var err error
+			conn, err = DefaultConnection()
+			if err != nil {
+				return cfg, err
+			}
+		}
+		endpoint, ok := ReceiptsEndpoint(conn)
+		if !ok {
+			return cfg, fmt.Errorf("no receipts endpoint configured and the connection is not over HTTP")
+		}
+		cfg.rcptsURL = endpoint
+	}
+	return cfg, nil
+}
+
+// FetchReceipt fetches the receipt for a task from the service receipts
+// endpoint. It returns `ErrReceiptNotFound` if the receipt is not available.
+//
+// The endpoint is that of the connection configured with `WithConnection`, or
+// the `DefaultConnection`, unless one is configured with
+// `WithReceiptsEndpoint`. The same applies to the other receipt functions.
+//
+// The `task` is the CID of the invocation the receipt is for.
+func FetchReceipt(ctx context.Context, task ipld.Link, options ...Option) (receipt.AnyReceipt, error) {
+	cfg, err := newReceiptsConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	return fetchReceipt(ctx, task, cfg)
+}
+
+// PollReceipt fetches the receipt for a task from the service receipts
+// endpoint, retrying while the receipt is not available. The polling interval
+// and maximum number of attempts can be configured with `WithPollInterval` and
+// `WithPollRetries`.
+//
+// The `task` is the CID of the invocation the receipt is for.
+func PollReceipt(ctx context.Context, task ipld.Link, options ...Option) (receipt.AnyReceipt, error) {
+	cfg, err := newReceiptsConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	return pollReceipt(ctx, task, cfg)
+}
+
+// PollReceiptWithReader is like `PollReceipt` but reads the receipt with the
+// passed typed receipt reader e.g. `blobaccept.NewReceiptReader()`.
+func PollReceiptWithReader[O, X any](ctx context.Context, task ipld.Link, reader receipt.ReceiptReader[O, X], options ...Option) (receipt.Receipt[O, X], error) {
+	cfg, err := newReceiptsConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	rcptlnk, blks, err := pollReceiptBlocks(ctx, task, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return reader.Read(rcptlnk, blks.Iterator())
+}
+
+// FollowEffects resolves the receipts for the fork and join effects of the
+// passed receipt, and the effects of those receipts, recursively.
+//
+// Receipts concluded by `ucan/conclude` effects are used when available,
+// otherwise receipts are polled for from the service receipts endpoint.
+func FollowEffects[O, X any](ctx context.Context, rcpt receipt.Receipt[O, X], options ...Option) (EffectReceipts, error) {
+	return followEffects(ctx, rcpt.Fx(), options)
+}
+
+func followEffects(ctx context.Context, effects fx.Effects, options []Option) (EffectReceipts, error) {
+	cfg, err := newReceiptsConfig(options)
+	if err != nil {
+		return EffectReceipts{}, err
+	}
+	r := effectResolver{cfg: cfg, trees: map[string]*ReceiptTree{}}
+	return r.resolve(ctx, effects)
+}
+
+type effectResolver struct {
+	cfg ClientConfig
+	// trees are the resolved receipt trees keyed by task CID. A nil value
+	// indicates resolution is in progress.
+	trees map[string]*ReceiptTree
+}
+
+func (r *effectResolver) resolve(ctx context.Context, effects fx.Effects) (EffectReceipts, error) {
+	concluded, err := concludedReceipts(effects)
+	if err != nil {
+		return EffectReceipts{}, err
+	}
+
+	resolved := EffectReceipts{}
+	for _, task := range effects.Fork() {
+		// conclude invocations deliver receipts for other tasks
+		if isConclude(task) {
+			continue
+		}
+		tree, err := r.resolveTask(ctx, task.Link(), concluded)
+		if err != nil {
+			return EffectReceipts{}, err
+		}
+		resolved.Fork = append(resolved.Fork, *tree)
+	}
+
+	if effects.Join().Link() != nil {
+		resolved.Join, err = r.resolveTask(ctx, effects.Join().Link(), concluded)
+		if err != nil {
+			return EffectReceipts{}, err
+		}
+	}
+
+	return resolved, nil
+}
+
+func (r *effectResolver) resolveTask(ctx context.Context, task ipld.Link, concluded map[string]concludedReceipt) (*ReceiptTree, error) {
+	key := task.String()
+	if tree, ok := r.trees[key]; ok {
+		if tree == nil {
+			return nil, fmt.Errorf("effect cycle detected: %s", key)
+		}
+		return tree, nil
+	}
+	r.trees[key] = nil
+
+	var rcpt receipt.AnyReceipt
+	if c, ok := concluded[key]; ok {
+		var err error
+		rcpt, err = readAnyReceipt(c.rcpt, c.blks)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		rcpt, err = pollReceipt(ctx, task, r.cfg)
+		if err != nil {
+			return nil, fmt.Errorf("resolving effect %s: %w", key, err)
+		}
+	}
+
+	effects, err := r.resolve(ctx, rcpt.Fx())
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &ReceiptTree{Receipt: rcpt, Effects: effects}
+	r.trees[key] = tree
+	return tree, nil
+}
+
+func isConclude(effect fx.Effect) bool {
+	inv, ok := effect.Invocation()
+	if !ok {
+		return false
+	}
+	caps := inv.Capabilities()
+	return len(caps) > 0 && caps[0].Can() == ucanconclude.Ability
+}
+
+type concludedReceipt struct {
+	rcpt ipld.Link
+	blks blockstore.BlockReader
+}
+
+// concludedReceipts collects the receipts delivered by `ucan/conclude` fork
+// effects, keyed by the CID of the task they are for.
+func concludedReceipts(effects fx.Effects) (map[string]concludedReceipt, error) {
+	concluded := map[string]concludedReceipt{}
+	for _, effect := range effects.Fork() {
+		if !isConclude(effect) {
+			continue
+		}
+		inv, _ := effect.Invocation()
+
+		rcptlnk, err := concludeReceiptLink(inv)
+		if err != nil {
+			return nil, err
+		}
+
+		blks, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(inv.Blocks()))
+		if err != nil {
+			return nil, fmt.Errorf("creating block reader: %s", err)
+		}
+
+		ranlnk, err := receiptRan(rcptlnk, blks)
+		if err != nil {
+			return nil, err
+		}
+
+		concluded[ranlnk.String()] = concludedReceipt{rcptlnk, blks}
+	}
+	return concluded, nil
+}
+
+// concludeReceiptLink returns the link to the receipt a `ucan/conclude`
+// invocation concludes.
+func concludeReceiptLink(inv invocation.Invocation) (ipld.Link, error) {
+	nb, ok := inv.Capabilities()[0].Nb().(ipld.Node)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s caveats type: %T", ucanconclude.Ability, inv.Capabilities()[0].Nb())
+	}
+	n, err := nb.LookupByString("receipt")
+	if err != nil {
+		return nil, fmt.Errorf("looking up %s receipt: %s", ucanconclude.Ability, err)
+	}
+	return n.AsLink()
+}
+
+// receiptRan returns the link to the invocation a receipt is for, without
+// needing to know the result types of the receipt.
+func receiptRan(rcpt ipld.Link, blks blockstore.BlockReader) (ipld.Link, error) {
+	blk, ok, err := blks.Get(rcpt)
+	if err != nil {
+		return nil, fmt.Errorf("getting receipt block: %s", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("missing receipt block: %s", rcpt)
+	}
+
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := dagcbor.Decode(nb, bytes.NewReader(blk.Bytes())); err != nil {
+		return nil, fmt.Errorf("decoding receipt: %s", err)
+	}
+
+	ocm, err := nb.Build().LookupByString("ocm")
+	if err != nil {
+		return nil, fmt.Errorf("looking up receipt outcome: %s", err)
+	}
+	r, err := ocm.LookupByString("ran")
+	if err != nil {
+		return nil, fmt.Errorf("looking up receipt ran: %s", err)
+	}
+	return r.AsLink()
+}
+
+func readAnyReceipt(rcpt ipld.Link, blks blockstore.BlockReader) (receipt.AnyReceipt, error) {
+	return receipt.NewReceipt[ipld.Node, ipld.Node](rcpt, blks, rdm.TypeSystem().TypeByName("Receipt"))
+}
+
+func pollReceipt(ctx context.Context, task ipld.Link, cfg ClientConfig) (receipt.AnyReceipt, error) {
+	rcptlnk, blks, err := pollReceiptBlocks(ctx, task, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return readAnyReceipt(rcptlnk, blks)
+}
+
+func pollReceiptBlocks(ctx context.Context, task ipld.Link, cfg ClientConfig) (ipld.Link, blockstore.BlockReader, error) {
+	var err error
+	for i := 0; i < cfg.pollRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(cfg.pollInterval):
+			}
+		}
+		var rcptlnk ipld.Link
+		var blks blockstore.BlockReader
+		rcptlnk, blks, err = fetchReceiptBlocks(ctx, task, cfg)
+		if err == nil {
+			return rcptlnk, blks, nil
+		}
+		if !errors.Is(err, ErrReceiptNotFound) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, fmt.Errorf("polling receipt after %d attempts: %w", cfg.pollRetries, err)
+}
+
+func fetchReceipt(ctx context.Context, task ipld.Link, cfg ClientConfig) (receipt.AnyReceipt, error) {
+	rcptlnk, blks, err := fetchReceiptBlocks(ctx, task, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return readAnyReceipt(rcptlnk, blks)
+}
+
+// fetchReceiptBlocks fetches the agent message containing the receipt for a
+// task and returns the receipt link along with the message blocks.
+func fetchReceiptBlocks(ctx context.Context, task ipld.Link, cfg ClientConfig) (ipld.Link, blockstore.BlockReader, error) {
+	rcptURL := cfg.rcptsURL.JoinPath(task.String())
+	req, err := http.NewRequestWithContext(ctx, "GET", rcptURL.String(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating HTTP request: %s", err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching receipt: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil, fmt.Errorf("%w: %s", ErrReceiptNotFound, task)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("fetching receipt: %s → %d", rcptURL, res.StatusCode)
+	}
+
+	msg, err := response.Decode(uhttp.NewHTTPResponse(res.StatusCode, res.Body, res.Header))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding receipt message: %s", err)
+	}
+
+	rcptlnk, ok := msg.Get(task)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrReceiptNotFound, task)
+	}
+
+	blks, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(msg.Blocks()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating block reader: %s", err)
+	}
+
+	return rcptlnk, blks, nil
+}
@@ -0,0 +1,251 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/invocation/ran"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/ok"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/transport/car/response"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+func task(t *testing.T, can string) invocation.Invocation {
+	t.Helper()
+	return helpers.Must(invocation.Invoke(
+		fixtures.Service,
+		fixtures.Service,
+		ucan.NewCapability(can, fixtures.Service.DID().String(), ucan.NoCaveats{}),
+	))
+}
+
+func issue(t *testing.T, inv invocation.Invocation, opts ...receipt.Option) receipt.AnyReceipt {
+	t.Helper()
+	return helpers.Must(receipt.Issue(
+		fixtures.Service,
+		result.Ok[ok.Unit, ipld.Builder](ok.Unit{}),
+		ran.FromInvocation(inv),
+		opts...,
+	))
+}
+
+type requestCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *requestCounter) inc(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[key]++
+	return c.counts[key]
+}
+
+func (c *requestCounter) get(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[key]
+}
+
+// receiptsServer serves receipts like the service receipts endpoint. A receipt
+// is not found until it has been requested `delay` times.
+func receiptsServer(t *testing.T, delay int, rcpts ...receipt.AnyReceipt) (*url.URL, *requestCounter) {
+	t.Helper()
+
+	byTask := map[string]receipt.AnyReceipt{}
+	for _, r := range rcpts {
+		byTask[r.Ran().Link().String()] = r
+	}
+
+	requests := &requestCounter{counts: map[string]int{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/receipt/")
+		n := requests.inc(key)
+		rcpt, ok := byTask[key]
+		if !ok || n <= delay {
+			http.NotFound(w, r)
+			return
+		}
+		msg := helpers.Must(message.Build(nil, []receipt.AnyReceipt{rcpt}))
+		res := helpers.Must(response.Encode(msg))
+		_, err := io.Copy(w, res.Body())
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	return helpers.Must(url.Parse(srv.URL + "/receipt/")), requests
+}
+
+func TestFollowEffects(t *testing.T) {
+	a := task(t, "test/a")
+	b := task(t, "test/b")
+	c := task(t, "test/c")
+
+	rcptA := issue(t, a)
+	rcptB := issue(t, b, receipt.WithFork(fx.FromLink(c.Link())))
+	rcptC := issue(t, c)
+	root := issue(t, task(t, "test/root"),
+		receipt.WithFork(fx.FromInvocation(a), fx.FromLink(b.Link())),
+		receipt.WithJoin(fx.FromLink(b.Link())),
+	)
+
+	endpoint, requests := receiptsServer(t, 2, rcptA, rcptB, rcptC)
+
+	effects, err := client.FollowEffects[ipld.Node, ipld.Node](
+		context.Background(),
+		root,
+		client.WithReceiptsEndpoint(endpoint),
+		client.WithPollInterval(time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, effects.Fork, 2)
+	require.Equal(t, rcptA.Root().Link(), effects.Fork[0].Receipt.Root().Link())
+	require.Equal(t, rcptB.Root().Link(), effects.Fork[1].Receipt.Root().Link())
+	require.NotNil(t, effects.Join)
+	require.Equal(t, rcptB.Root().Link(), effects.Join.Receipt.Root().Link())
+
+	require.Len(t, effects.Join.Effects.Fork, 1)
+	require.Equal(t, rcptC.Root().Link(), effects.Join.Effects.Fork[0].Receipt.Root().Link())
+
+	// each receipt is fetched once it is available and never again
+	require.Equal(t, 3, requests.get(b.Link().String()))
+}
+
+func TestPollReceipt(t *testing.T) {
+	a := task(t, "test/a")
+	endpoint, requests := receiptsServer(t, 5, issue(t, a))
+
+	_, err := client.PollReceipt(
+		context.Background(),
+		a.Link(),
+		client.WithReceiptsEndpoint(endpoint),
+		client.WithPollInterval(time.Millisecond),
+		client.WithPollRetries(3),
+	)
+	require.ErrorIs(t, err, client.ErrReceiptNotFound)
+	require.Equal(t, 3, requests.get(a.Link().String()))
+
+	rcpt, err := client.PollReceipt(
+		context.Background(),
+		a.Link(),
+		client.WithReceiptsEndpoint(endpoint),
+		client.WithPollInterval(time.Millisecond),
+	)
+	require.NoError(t, err)
+	require.Equal(t, a.Link(), rcpt.Ran().Link())
+}
+
+func TestPollReceiptRetries(t *testing.T) {
+	a := task(t, "test/a")
+	endpoint, requests := receiptsServer(t, 0, issue(t, a))
+
+	for _, retries := range []int{0, -1} {
+		_, err := client.PollReceipt(
+			context.Background(),
+			a.Link(),
+			client.WithReceiptsEndpoint(endpoint),
+			client.WithPollRetries(retries),
+		)
+		require.ErrorContains(t, err, "poll retries must be at least 1")
+	}
+	require.Equal(t, 0, requests.get(a.Link().String()))
+
+	rcpt, err := client.PollReceipt(
+		context.Background(),
+		a.Link(),
+		client.WithReceiptsEndpoint(endpoint),
+		client.WithPollRetries(1),
+	)
+	require.NoError(t, err)
+	require.Equal(t, a.Link(), rcpt.Ran().Link())
+	require.Equal(t, 1, requests.get(a.Link().String()))
+}
+
+func TestPollReceiptCanceled(t *testing.T) {
+	a := task(t, "test/a")
+	endpoint, requests := receiptsServer(t, 5, issue(t, a))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.PollReceipt(
+		ctx,
+		a.Link(),
+		client.WithReceiptsEndpoint(endpoint),
+		client.WithPollInterval(time.Hour),
+	)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, requests.get(a.Link().String()))
+}
+
+func TestReceiptsEndpointFromConnection(t *testing.T) {
+	// the default service must not be used
+	t.Setenv("W3UP_SERVICE_URL", "http://127.0.0.1:1")
+
+	a := task(t, "test/a")
+	b := task(t, "test/b")
+	root := issue(t, task(t, "test/root"), receipt.WithFork(fx.FromLink(b.Link())))
+	endpoint, _ := receiptsServer(t, 0, issue(t, a), issue(t, b))
+	serviceURL := helpers.Must(url.Parse(strings.TrimSuffix(endpoint.String(), "receipt/")))
+	conn := helpers.Must(client.NewConnection(serviceURL, fixtures.Service.DID()))
+
+	derived, ok := client.ReceiptsEndpoint(conn)
+	require.True(t, ok)
+	require.Equal(t, endpoint.String(), derived.String())
+
+	t.Run("functions", func(t *testing.T) {
+		rcpt, err := client.PollReceipt(context.Background(), a.Link(), client.WithConnection(conn))
+		require.NoError(t, err)
+		require.Equal(t, a.Link(), rcpt.Ran().Link())
+	})
+
+	t.Run("client", func(t *testing.T) {
+		c := helpers.Must(client.NewClient(fixtures.Alice, client.WithConnection(conn)))
+		require.Equal(t, endpoint.String(), c.ReceiptsEndpoint().String())
+
+		rcpt, err := c.PollReceipt(context.Background(), a.Link())
+		require.NoError(t, err)
+		require.Equal(t, a.Link(), rcpt.Ran().Link())
+
+		effects, err := c.FollowEffects(context.Background(), root.Fx())
+		require.NoError(t, err)
+		require.Len(t, effects.Fork, 1)
+		require.Equal(t, b.Link(), effects.Fork[0].Receipt.Ran().Link())
+	})
+
+	t.Run("override", func(t *testing.T) {
+		other := helpers.Must(url.Parse("http://127.0.0.1:1/receipt/"))
+		c := helpers.Must(client.NewClient(fixtures.Alice, client.WithConnection(conn), client.WithReceiptsEndpoint(other)))
+		require.Equal(t, other, c.ReceiptsEndpoint())
+
+		_, err := c.FetchReceipt(context.Background(), a.Link(), client.WithReceiptsEndpoint(endpoint))
+		require.NoError(t, err)
+	})
+
+	t.Run("not over HTTP", func(t *testing.T) {
+		conn := newTestConnection(t)
+		_, ok := client.ReceiptsEndpoint(conn)
+		require.False(t, ok)
+
+		_, err := client.PollReceipt(context.Background(), a.Link(), client.WithConnection(conn))
+		require.Error(t, err)
+	})
+}
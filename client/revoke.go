@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/capability/ucanrevoke"
+	"github.com/storacha/go-w3up/proof"
+)
+
+// Revoke revokes a delegation, so that it can no longer be used as a proof.
+// The issuer must be the issuer of the delegation or of one of the delegations
+// in its proof chain. The delegation and the proof chain that shows the issuer
+// is an issuer upstream are attached to the invocation.
+//
+// If a proof store is configured with `WithProofStore`, proofs in the chain
+// are resolved from it, and the delegation is marked as revoked in it once the
+// service confirms the revocation.
+//
+// The `ctx` aborts the request to the service when canceled.
+//
+// The `issuer` is the signing authority that is issuing the UCAN invocation.
+//
+// The `dlg` is the delegation to revoke.
+func Revoke(ctx context.Context, issuer principal.Signer, dlg delegation.Delegation, options ...Option) (receipt.Receipt[*ucanrevoke.Success, *ucanrevoke.Failure], error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	store := cfg.prfs
+	if store == nil {
+		store = proof.NewMemoryStore()
+	}
+	chain, ok, err := proof.Path(store, dlg, issuer.DID())
+	if err != nil {
+		return nil, fmt.Errorf("resolving proof chain: %s", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s is not an issuer of delegation %s or its proofs", issuer.DID(), dlg.Link())
+	}
+
+	nb := ucanrevoke.Caveat{Ucan: dlg.Link()}
+	for _, prf := range chain {
+		nb.Proof = append(nb.Proof, prf.Link())
+	}
+
+	inv, err := NewInvocation(issuer, ucanrevoke.NewCapability(issuer.DID(), nb), options...)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range append([]delegation.Delegation{dlg}, chain...) {
+		for blk, err := range d.Blocks() {
+			if err != nil {
+				return nil, fmt.Errorf("reading delegation blocks: %s", err)
+			}
+			if err := inv.Attach(blk); err != nil {
+				return nil, fmt.Errorf("attaching delegation block: %s", err)
+			}
+		}
+	}
+
+	reader, err := ucanrevoke.NewReceiptReader()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ExecuteBatch(ctx, []invocation.Invocation{inv}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	rcpt, err := ReadReceipt(resp, inv.Link(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, x := result.Unwrap(rcpt.Out()); x == nil && cfg.prfs != nil {
+		if _, ok, err := cfg.prfs.Get(dlg.Link()); err == nil && ok {
+			if err := cfg.prfs.Revoke(dlg.Link()); err != nil {
+				return nil, fmt.Errorf("marking delegation revoked: %s", err)
+			}
+		}
+	}
+
+	return rcpt, nil
+}
+
+// RevokeLink revokes the delegation with the passed CID, which must be in the
+// proof store configured with `WithProofStore`. See `Revoke`.
+func RevokeLink(ctx context.Context, issuer principal.Signer, link ipld.Link, options ...Option) (receipt.Receipt[*ucanrevoke.Success, *ucanrevoke.Failure], error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.prfs == nil {
+		return nil, fmt.Errorf("no proof store configured")
+	}
+	dlg, ok, err := cfg.prfs.Get(link)
+	if err != nil {
+		return nil, fmt.Errorf("reading delegation: %s", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", proof.ErrNotFound, link)
+	}
+	return Revoke(ctx, issuer, dlg, options...)
+}
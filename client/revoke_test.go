@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/ucanrevoke"
+	"github.com/storacha/go-w3up/client"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/stretchr/testify/require"
+)
+
+type revokeSuccess struct{}
+
+func (revokeSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "time", qp.Int(int64(ucan.Now())))
+	})
+}
+
+// revocation is a `ucan/revoke` invocation received by the test service.
+type revocation struct {
+	ucan   ipld.Link
+	proofs []ipld.Link
+	// attached reports whether the blocks of the revoked delegation were
+	// attached to the invocation.
+	attached bool
+}
+
+func TestRevoke(t *testing.T) {
+	var revoked []revocation
+	conn := newTestConnection(t, provide(ucanrevoke.Ability, func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (revokeSuccess, fx.Effects, error) {
+		r := revocation{ucan: helpers.Must(helpers.Must(cap.Nb().LookupByString("ucan")).AsLink())}
+		prfs := helpers.Must(cap.Nb().LookupByString("proof")).ListIterator()
+		for !prfs.Done() {
+			_, n, err := prfs.Next()
+			require.NoError(t, err)
+			r.proofs = append(r.proofs, helpers.Must(n.AsLink()))
+		}
+		bs := helpers.Must(blockstore.NewBlockReader(blockstore.WithBlocksIterator(inv.Blocks())))
+		_, r.attached, _ = bs.Get(r.ucan)
+		revoked = append(revoked, r)
+		return revokeSuccess{}, nil, nil
+	}))
+
+	// alice is a space that delegates to bob, the agent, who delegates to mallory
+	space := helpers.Must(delegation.Delegate(
+		fixtures.Alice,
+		fixtures.Bob,
+		[]ucan.Capability[ucan.NoCaveats]{ucan.NewCapability("*", fixtures.Alice.DID().String(), ucan.NoCaveats{})},
+		delegation.WithNoExpiration(),
+	))
+	shared := helpers.Must(delegation.Delegate(
+		fixtures.Bob,
+		fixtures.Mallory,
+		[]ucan.Capability[ucan.NoCaveats]{ucan.NewCapability("upload/add", fixtures.Alice.DID().String(), ucan.NoCaveats{})},
+		delegation.WithNoExpiration(),
+		delegation.WithProof(delegation.FromLink(space.Link())),
+	))
+
+	t.Run("issuer", func(t *testing.T) {
+		revoked = nil
+		store := proof.NewMemoryStore(space, shared)
+		c := helpers.Must(client.NewClient(fixtures.Bob, client.WithConnection(conn), client.WithProofStore(store)))
+
+		rcpt, err := c.Revoke(context.Background(), shared.Link())
+		require.NoError(t, err)
+		_, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+
+		require.Len(t, revoked, 1)
+		require.Equal(t, shared.Link(), revoked[0].ucan)
+		require.Empty(t, revoked[0].proofs)
+		require.True(t, revoked[0].attached)
+
+		ok, err := store.Revoked(shared.Link())
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("upstream issuer", func(t *testing.T) {
+		revoked = nil
+		store := proof.NewMemoryStore(space)
+
+		rcpt, err := client.Revoke(context.Background(), fixtures.Alice, shared, client.WithConnection(conn), client.WithProofStore(store))
+		require.NoError(t, err)
+		_, x := result.Unwrap(rcpt.Out())
+		require.Nil(t, x)
+
+		require.Len(t, revoked, 1)
+		require.Equal(t, []ipld.Link{space.Link()}, revoked[0].proofs)
+	})
+
+	t.Run("not an issuer", func(t *testing.T) {
+		revoked = nil
+		store := proof.NewMemoryStore(space, shared)
+
+		_, err := client.Revoke(context.Background(), fixtures.Mallory, shared, client.WithConnection(conn), client.WithProofStore(store))
+		require.Error(t, err)
+		require.Empty(t, revoked)
+	})
+
+	t.Run("not in store", func(t *testing.T) {
+		c := helpers.Must(client.NewClient(fixtures.Bob, client.WithConnection(conn), client.WithProofStore(proof.NewMemoryStore())))
+		_, err := c.Revoke(context.Background(), shared.Link())
+		require.ErrorIs(t, err, proof.ErrNotFound)
+	})
+}
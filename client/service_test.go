@@ -0,0 +1,153 @@
+package client_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/invocation/ran"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	fdm "github.com/storacha/go-ucanto/core/result/failure/datamodel"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/transport"
+	"github.com/storacha/go-ucanto/transport/car"
+	"github.com/storacha/go-ucanto/ucan"
+	cdg "github.com/storacha/go-w3up/delegation"
+)
+
+// serviceMethod handles invocations of an ability on the test service.
+type serviceMethod struct {
+	can    string
+	handle func(inv invocation.Invocation) (result.Result[ipld.Builder, ipld.Builder], fx.Effects, error)
+}
+
+// provide creates a service method for the test service that accepts any
+// caveats for the ability. Invocations must be issued by the resource or
+// carry a proof that the resource delegated the ability to the issuer.
+func provide[O ipld.Builder](can string, handler func(cap ucan.Capability[ipld.Node], inv invocation.Invocation) (O, fx.Effects, error)) serviceMethod {
+	return serviceMethod{can, func(inv invocation.Invocation) (result.Result[ipld.Builder, ipld.Builder], fx.Effects, error) {
+		c := inv.Capabilities()[0]
+		if err := authorize(inv, c); err != nil {
+			name := "Unauthorized"
+			return result.Error[ipld.Builder, ipld.Builder](failure.FromFailureModel(fdm.FailureModel{Name: &name, Message: err.Error()})), nil, nil
+		}
+		nb, ok := c.Nb().(ipld.Node)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected caveats: not an IPLD node")
+		}
+		o, effects, err := handler(ucan.NewCapability(c.Can(), c.With(), nb), inv)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Ok[ipld.Builder, ipld.Builder](o), effects, nil
+	}}
+}
+
+// authorize checks the issuer of the invocation may invoke the capability,
+// with the service attesting to delegations from accounts.
+func authorize(inv invocation.Invocation, c ucan.Capability[any]) error {
+	if inv.Issuer().DID().String() == c.With() {
+		return nil
+	}
+	bs, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(inv.Blocks()))
+	if err != nil {
+		return err
+	}
+	var prfs []delegation.Delegation
+	for _, l := range inv.Proofs() {
+		prf, err := delegation.NewDelegationView(l, bs)
+		if err != nil {
+			return err
+		}
+		prfs = append(prfs, prf)
+	}
+	err = fmt.Errorf("%s has no proof of %s on %s", inv.Issuer().DID(), c.Can(), c.With())
+	for _, prf := range prfs {
+		err = cdg.Validate(prf, inv.Issuer().DID(), c.Can(), c.With(), ucan.Now(), cdg.WithAuthority(fixtures.Service.Verifier()), cdg.WithAttestations(prfs...))
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// testService is a local stand-in for the service, identified by
+// `fixtures.Service`. It issues a receipt for each invocation in a request.
+type testService struct {
+	methods map[string]serviceMethod
+}
+
+func (s testService) Request(req transport.HTTPRequest) (transport.HTTPResponse, error) {
+	selection, aerr := car.NewCARInboundCodec().Accept(req)
+	if aerr != nil {
+		return nil, aerr
+	}
+	msg, err := selection.Decoder().Decode(req)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(msg.Blocks()))
+	if err != nil {
+		return nil, err
+	}
+
+	var rcpts []receipt.AnyReceipt
+	for _, l := range msg.Invocations() {
+		inv, err := invocation.NewInvocationView(l, bs)
+		if err != nil {
+			return nil, err
+		}
+		rcpt, err := s.run(inv)
+		if err != nil {
+			return nil, err
+		}
+		rcpts = append(rcpts, rcpt)
+	}
+
+	res, err := message.Build(nil, rcpts)
+	if err != nil {
+		return nil, err
+	}
+	return selection.Encoder().Encode(res)
+}
+
+func (s testService) run(inv invocation.Invocation) (receipt.AnyReceipt, error) {
+	caps := inv.Capabilities()
+	if len(caps) != 1 {
+		return receipt.Issue(fixtures.Service, result.NewFailure(fmt.Errorf("expected a single capability, got %d", len(caps))), ran.FromInvocation(inv))
+	}
+	m, ok := s.methods[caps[0].Can()]
+	if !ok {
+		return receipt.Issue(fixtures.Service, result.NewFailure(fmt.Errorf("no handler for %s", caps[0].Can())), ran.FromInvocation(inv))
+	}
+
+	out, effects, err := m.handle(inv)
+	if err != nil {
+		return receipt.Issue(fixtures.Service, result.NewFailure(err), ran.FromInvocation(inv))
+	}
+	var opts []receipt.Option
+	if effects != nil {
+		opts = append(opts, receipt.WithJoin(effects.Join()), receipt.WithFork(effects.Fork()...))
+	}
+	return receipt.Issue(fixtures.Service, out, ran.FromInvocation(inv), opts...)
+}
+
+// newTestConnection creates a connection to a local stand-in for the service,
+// identified by `fixtures.Service`.
+func newTestConnection(t *testing.T, methods ...serviceMethod) client.Connection {
+	t.Helper()
+	s := testService{methods: map[string]serviceMethod{}}
+	for _, m := range methods {
+		s.methods[m.can] = m
+	}
+	return helpers.Must(client.NewConnection(fixtures.Service, s))
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/transport"
+	uhttp "github.com/storacha/go-ucanto/transport/http"
+)
+
+// ContextChannel is a transport channel that can abort requests when a context
+// is canceled.
+type ContextChannel interface {
+	transport.Channel
+	RequestWithContext(ctx context.Context, req transport.HTTPRequest) (transport.HTTPResponse, error)
+}
+
+type httpChannel struct {
+	url    *url.URL
+	client *http.Client
+}
+
+var _ ContextChannel = (*httpChannel)(nil)
+
+// URL returns the URL of the service requests are sent to.
+func (c *httpChannel) URL() *url.URL {
+	return c.url
+}
+
+func (c *httpChannel) Request(req transport.HTTPRequest) (transport.HTTPResponse, error) {
+	return c.RequestWithContext(context.Background(), req)
+}
+
+func (c *httpChannel) RequestWithContext(ctx context.Context, req transport.HTTPRequest) (transport.HTTPResponse, error) {
+	hr, err := http.NewRequestWithContext(ctx, "POST", c.url.String(), req.Body())
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP request: %s", err)
+	}
+
+	hr.Header = req.Headers()
+	res, err := c.client.Do(hr)
+	if err != nil {
+		return nil, fmt.Errorf("doing HTTP request: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, uhttp.NewHTTPError(fmt.Sprintf("HTTP Request failed. %s %s → %d", hr.Method, c.url.String(), res.StatusCode), res.StatusCode, res.Header)
+	}
+
+	return uhttp.NewHTTPResponse(res.StatusCode, res.Body, res.Header), nil
+}
+
+// NewHTTPChannel creates a channel that sends requests to the service over
+// HTTP. Requests sent with a context are aborted when the context is canceled.
+func NewHTTPChannel(url *url.URL) ContextChannel {
+	return &httpChannel{url: url, client: &http.Client{}}
+}
+
+// execute sends the invocations to the service and returns the response. If
+// the connection channel is a `ContextChannel` the request is aborted when the
+// context is canceled, otherwise execute stops waiting for the response.
+func execute(ctx context.Context, invocations []invocation.Invocation, conn client.Connection) (client.ExecutionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	input, err := message.Build(invocations, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building message: %s", err)
+	}
+
+	req, err := conn.Codec().Encode(input)
+	if err != nil {
+		return nil, fmt.Errorf("encoding message: %s", err)
+	}
+
+	res, err := request(ctx, conn.Channel(), req)
+	if err != nil {
+		return nil, fmt.Errorf("sending message: %w", err)
+	}
+
+	output, err := conn.Codec().Decode(res)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("decoding message: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("decoding message: %s", err)
+	}
+
+	return client.ExecutionResponse(output), nil
+}
+
+func request(ctx context.Context, channel transport.Channel, req transport.HTTPRequest) (transport.HTTPResponse, error) {
+	if cc, ok := channel.(ContextChannel); ok {
+		return cc.RequestWithContext(ctx, req)
+	}
+
+	type result struct {
+		res transport.HTTPResponse
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := channel.Request(req)
+		done <- result{res, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.res, r.err
+	}
+}
@@ -0,0 +1,45 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	ucanto "github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-w3up/capability/uploadlist"
+	"github.com/storacha/go-w3up/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelInvocation(t *testing.T) {
+	// the service never responds
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-unblock:
+		}
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	channel := client.NewHTTPChannel(helpers.Must(url.Parse(srv.URL)))
+	conn := helpers.Must(ucanto.NewConnection(fixtures.Service, channel))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.UploadList(
+		ctx,
+		fixtures.Alice,
+		fixtures.Alice.DID(),
+		uploadlist.Caveat{},
+		client.WithConnection(conn),
+	)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	edverifier "github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"github.com/storacha/go-ucanto/principal/verifier"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/ucanrevoke"
+	"github.com/storacha/go-w3up/client"
+	"github.com/storacha/go-w3up/cmd/util"
+	cdg "github.com/storacha/go-w3up/delegation"
+	"github.com/storacha/go-w3up/keystore"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/storacha/go-w3up/space"
+	"github.com/urfave/cli/v2"
+)
+
+var delegationCommand = &cli.Command{
+	Name:  "delegation",
+	Usage: "Create and manage delegations issued by the agent.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "create",
+			Usage:     "Delegate capabilities on a space to another DID. The delegation is written to stdout as a CAR file unless --output or --base64 is passed. It is not added to the agent proof store.",
+			ArgsUsage: "<audience>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "space",
+					Value: "",
+					Usage: "DID or name of space to delegate capabilities on. Defaults to the issuer space when the delegation is issued as a space, or the current space.",
+				},
+				&cli.BoolFlag{
+					Name:  "issuer-mnemonic",
+					Value: false,
+					Usage: "Issue the delegation as a space, recovered from its mnemonic read from stdin. By default the agent issues the delegation with its proofs.",
+				},
+				&cli.StringFlag{
+					Name:  "issuer-key",
+					Value: "",
+					Usage: "Issue the delegation as a space, with its private key read from the file at this path in any format accepted by `w3 key import`.",
+				},
+				&cli.StringSliceFlag{
+					Name:     "can",
+					Aliases:  []string{"c"},
+					Usage:    "Ability to delegate e.g. upload/add. May be repeated.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    "expiration",
+					Aliases: []string{"e"},
+					Value:   "",
+					Usage:   "When the delegation expires, as a duration from now (e.g. 24h) or a Unix timestamp. Defaults to no expiration.",
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Value:   "",
+					Usage:   "Path of file to write the delegation to.",
+				},
+				&cli.BoolFlag{
+					Name:  "base64",
+					Value: false,
+					Usage: "Format the delegation as a base64 string.",
+				},
+			},
+			Action: delegationCreate,
+		},
+		{
+			Name:      "revoke",
+			Usage:     "Revoke a delegation issued by this agent, or by an issuer the agent holds proofs from. The delegation is passed as a path to a delegation file, such as one written by `delegation create`. Each delegation in the file is revoked.",
+			ArgsUsage: "<path>",
+			Action:    delegationRevoke,
+		},
+		{
+			Name:      "inspect",
+			Usage:     "Print the delegation chain of each delegation in a file as a tree, and check whether it is valid for each capability it grants. Delegations issued by accounts are valid only with a ucan/attest attestation from the service, found in the file or the agent proof store.",
+			ArgsUsage: "<path>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "service-key",
+					Value: "",
+					Usage: "did:key of the service, to verify its attestations when the service DID is not a did:key.",
+				},
+			},
+			Action: delegationInspect,
+		},
+	},
+}
+
+func delegationCreate(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("expected a single audience DID, after any options: w3 delegation create [options] <audience>")
+	}
+	audience := util.MustParseDID(cCtx.Args().First())
+	abilities := cCtx.StringSlice("can")
+
+	var options []delegation.Option
+	if exp := cCtx.String("expiration"); exp != "" {
+		options = append(options, delegation.WithExpiration(mustParseExpiration(exp)))
+	}
+
+	var space did.DID
+	signer := mustGetSpaceIssuer(cCtx)
+	if signer != nil {
+		// the space key needs no proofs to delegate on its own space
+		space = signer.DID()
+		if cCtx.String("space") != "" && mustGetSpace(cCtx) != space {
+			log.Fatalf("a space can only delegate capabilities on itself: --space must be the issuer space %s", space)
+		}
+	} else {
+		space = mustGetSpace(cCtx)
+		signer = util.MustGetSigner()
+		options = append(options, delegation.WithProof(mustSelectProofs(signer.DID(), space, abilities)...))
+	}
+
+	dlg, err := cdg.Create(signer, audience, space.String(), abilities, options...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var out []byte
+	if cCtx.Bool("base64") {
+		s, err := cdg.Format(dlg)
+		if err != nil {
+			log.Fatalf("formatting delegation: %s", err)
+		}
+		out = []byte(s + "\n")
+	} else {
+		out, err = cdg.Archive(dlg)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cCtx.String("output") != "" {
+		if err := os.WriteFile(cCtx.String("output"), out, 0644); err != nil {
+			log.Fatalf("writing delegation: %s", err)
+		}
+		return nil
+	}
+	if _, err := os.Stdout.Write(out); err != nil {
+		log.Fatalf("writing delegation: %s", err)
+	}
+	return nil
+}
+
+// mustGetSpaceIssuer returns the space signer passed with --issuer-mnemonic or
+// --issuer-key, or nil if the agent issues the delegation.
+func mustGetSpaceIssuer(cCtx *cli.Context) principal.Signer {
+	switch {
+	case cCtx.Bool("issuer-mnemonic") && cCtx.String("issuer-key") != "":
+		log.Fatalf("pass only one of --issuer-mnemonic and --issuer-key")
+	case cCtx.Bool("issuer-mnemonic"):
+		s, err := space.FromMnemonic(mustReadMnemonic())
+		if err != nil {
+			log.Fatalf("recovering space key: %s", err)
+		}
+		return s
+	case cCtx.String("issuer-key") != "":
+		b, err := os.ReadFile(cCtx.String("issuer-key"))
+		if err != nil {
+			log.Fatalf("reading space key: %s", err)
+		}
+		s, err := keystore.DecodeKey(b, keystore.FormatAuto)
+		if err != nil {
+			log.Fatalf("decoding space key: %s", err)
+		}
+		return s
+	}
+	return nil
+}
+
+// mustSelectProofs returns the proofs in the agent proof store that the agent
+// can use to delegate each of the abilities on the space.
+func mustSelectProofs(agent did.DID, space did.DID, abilities []string) []delegation.Proof {
+	store := util.MustGetProofStore()
+
+	var prfs []delegation.Proof
+	seen := map[string]struct{}{}
+	for _, can := range abilities {
+		dlgs, err := proof.Select(store, agent, can, space.String())
+		if err != nil {
+			log.Fatalf("selecting proofs: %s", err)
+		}
+		if len(dlgs) == 0 {
+			log.Fatalf("agent has no proof of %s on %s", can, space)
+		}
+		for _, dlg := range dlgs {
+			if _, ok := seen[dlg.Link().String()]; ok {
+				continue
+			}
+			seen[dlg.Link().String()] = struct{}{}
+			prfs = append(prfs, delegation.FromDelegation(dlg))
+		}
+	}
+	return prfs
+}
+
+func delegationRevoke(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing delegation path")
+	}
+
+	signer := util.MustGetSigner()
+	options := []client.Option{
+		client.WithConnection(util.MustGetConnection(cCtx.String("service-url"), cCtx.String("service-did"))),
+		client.WithProofStore(util.MustGetProofStore()),
+	}
+
+	dlgs := util.MustGetProofs(cCtx.Args().First())
+	for _, dlg := range dlgs {
+		rcpt, err := client.Revoke(cCtx.Context, signer, dlg, options...)
+		if err != nil {
+			log.Fatalf("revoking delegation: %s", err)
+		}
+
+		_, revokeFailure := result.Unwrap(rcpt.Out())
+		if revokeFailure != nil {
+			fatalFailure(ucanrevoke.Ability, revokeFailure)
+		}
+
+		fmt.Printf("Revoked %s\n", dlg.Link())
+	}
+	return nil
+}
+
+func delegationInspect(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing path to delegation")
+	}
+
+	dlgs := util.MustGetProofs(cCtx.Args().First())
+	options := []cdg.ValidateOption{cdg.WithAttestations(mustGetAttestations(dlgs)...)}
+	if authority := mustGetAuthority(cCtx); authority != nil {
+		options = append(options, cdg.WithAuthority(authority))
+	}
+
+	now := ucan.Now()
+	for i, dlg := range dlgs {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		bs, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(dlg.Blocks()))
+		if err != nil {
+			log.Fatalf("creating block reader: %s", err)
+		}
+		printDelegationTree(bs, dlg, "", "")
+
+		fmt.Println()
+		for _, c := range dlg.Capabilities() {
+			if err := cdg.Validate(dlg, dlg.Audience().DID(), c.Can(), c.With(), now, options...); err != nil {
+				status := "invalid"
+				if errors.Is(err, cdg.ErrUnattested) {
+					status = "unverified"
+				}
+				fmt.Printf("%s %s: %s\n", c.Can(), c.With(), status)
+				for _, line := range strings.Split(err.Error(), "\n") {
+					fmt.Printf("\t%s\n", line)
+				}
+				continue
+			}
+			fmt.Printf("%s %s: valid\n", c.Can(), c.With())
+		}
+	}
+	return nil
+}
+
+// mustGetAttestations returns the `ucan/attest` attestations among the
+// delegations and in the agent proof store.
+func mustGetAttestations(dlgs []delegation.Delegation) []delegation.Delegation {
+	var attestations []delegation.Delegation
+	for _, dlg := range dlgs {
+		if len(proof.Attests(dlg)) > 0 {
+			attestations = append(attestations, dlg)
+		}
+	}
+	for dlg, err := range util.MustGetProofStore().All() {
+		if err != nil {
+			log.Fatalf("reading proofs: %s", err)
+		}
+		if len(proof.Attests(dlg)) > 0 {
+			attestations = append(attestations, dlg)
+		}
+	}
+	return attestations
+}
+
+// mustGetAuthority returns the verifier of the service that attests to
+// delegations issued by accounts, or nil if its key is not known: the service
+// DID is not a did:key and no --service-key was passed.
+func mustGetAuthority(cCtx *cli.Context) principal.Verifier {
+	id := util.MustParseDID(cCtx.String("service-did"))
+	key := cCtx.String("service-key")
+	if key == "" {
+		if !strings.HasPrefix(id.String(), "did:key:") {
+			return nil
+		}
+		key = id.String()
+	}
+	v, err := edverifier.Parse(key)
+	if err != nil {
+		log.Fatalf("parsing service key: %s", err)
+	}
+	if v.DID() == id {
+		return v
+	}
+	w, err := verifier.Wrap(v, id)
+	if err != nil {
+		log.Fatalf("creating service verifier: %s", err)
+	}
+	return w
+}
+
+// printDelegationTree prints the delegation and, indented below it, the
+// delegations in its proof chain.
+func printDelegationTree(bs blockstore.BlockReader, dlg delegation.Delegation, prefix string, branch string) {
+	fmt.Printf("%s%s%s\n", prefix, branch, dlg.Link())
+
+	switch branch {
+	case "├─ ":
+		prefix += "│  "
+	case "└─ ":
+		prefix += "   "
+	}
+
+	fmt.Printf("%s  issuer: %s\n", prefix, dlg.Issuer().DID())
+	fmt.Printf("%s  audience: %s\n", prefix, dlg.Audience().DID())
+	if exp := dlg.Expiration(); exp != nil {
+		fmt.Printf("%s  expires: %s\n", prefix, time.Unix(int64(*exp), 0).UTC().Format(time.RFC3339))
+	} else {
+		fmt.Printf("%s  expires: never\n", prefix)
+	}
+	for _, c := range dlg.Capabilities() {
+		fmt.Printf("%s  can: %s %s\n", prefix, c.Can(), c.With())
+	}
+
+	prfs := dlg.Proofs()
+	for i, link := range prfs {
+		branch := "├─ "
+		if i == len(prfs)-1 {
+			branch = "└─ "
+		}
+		prf, err := delegation.NewDelegationView(link, bs)
+		if err != nil {
+			fmt.Printf("%s%s%s (not included)\n", prefix, branch, link)
+			continue
+		}
+		printDelegationTree(bs, prf, prefix, branch)
+	}
+}
+
+// mustParseExpiration parses an expiration passed as a duration from now or a
+// Unix timestamp, returning the Unix timestamp.
+func mustParseExpiration(s string) int {
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return int(ts)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Fatalf("invalid expiration, expected a duration or Unix timestamp: %s", s)
+	}
+	return int(time.Now().Add(d).Unix())
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/invocation/ran"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	tcar "github.com/storacha/go-ucanto/transport/car"
+	uhttp "github.com/storacha/go-ucanto/transport/http"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/capability/ucanrevoke"
+	"github.com/stretchr/testify/require"
+)
+
+type revokeSuccess struct{}
+
+func (revokeSuccess) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "time", qp.Int(int64(ucan.Now())))
+	})
+}
+
+// newRevokeService starts a stand-in for the service, identified by
+// `fixtures.Service`, that accepts any `ucan/revoke` invocation and records
+// the CIDs of the revoked delegations.
+func newRevokeService(t *testing.T) (*httptest.Server, *[]ipld.Link) {
+	t.Helper()
+	var revoked []ipld.Link
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selection, aerr := tcar.NewCARInboundCodec().Accept(uhttp.NewHTTPRequest(r.Body, r.Header))
+		require.Nil(t, aerr)
+		msg := helpers.Must(selection.Decoder().Decode(uhttp.NewHTTPRequest(r.Body, r.Header)))
+		bs := helpers.Must(blockstore.NewBlockReader(blockstore.WithBlocksIterator(msg.Blocks())))
+
+		var rcpts []receipt.AnyReceipt
+		for _, l := range msg.Invocations() {
+			inv := helpers.Must(invocation.NewInvocationView(l, bs))
+			c := inv.Capabilities()[0]
+			require.Equal(t, ucanrevoke.Ability, c.Can())
+			nb, ok := c.Nb().(ipld.Node)
+			require.True(t, ok)
+			revoked = append(revoked, helpers.Must(helpers.Must(nb.LookupByString("ucan")).AsLink()))
+
+			out := result.Ok[ipld.Builder, ipld.Builder](revokeSuccess{})
+			rcpts = append(rcpts, helpers.Must(receipt.Issue(fixtures.Service, out, ran.FromInvocation(inv))))
+		}
+
+		res := helpers.Must(selection.Encoder().Encode(helpers.Must(message.Build(nil, rcpts))))
+		for k, v := range res.Headers() {
+			w.Header()[k] = v
+		}
+		_, err := io.Copy(w, res.Body())
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &revoked
+}
+
+func TestDelegationRevoke(t *testing.T) {
+	t.Setenv("W3UP_CONFIG_DIR", t.TempDir())
+	t.Setenv("W3UP_PRIVATE_KEY", helpers.Must(signer.Format(fixtures.Alice)))
+
+	srv, revoked := newRevokeService(t)
+	run := func(args ...string) error {
+		return newApp().RunContext(context.Background(), append([]string{
+			"w3",
+			"--service-url", srv.URL,
+			"--service-did", fixtures.Service.DID().String(),
+		}, args...))
+	}
+
+	// alice, the agent, delegates to bob and mallory, writing both delegations
+	// to the same file
+	var dlgs []delegation.Delegation
+	for _, audience := range []ucan.Principal{fixtures.Bob, fixtures.Mallory} {
+		dlgs = append(dlgs, helpers.Must(delegation.Delegate(
+			fixtures.Alice,
+			audience,
+			[]ucan.Capability[ucan.NoCaveats]{
+				ucan.NewCapability("upload/list", fixtures.Alice.DID().String(), ucan.NoCaveats{}),
+			},
+		)))
+	}
+
+	t.Run("path", func(t *testing.T) {
+		file := path.Join(t.TempDir(), "delegation.car")
+		require.NoError(t, os.WriteFile(file, helpers.Must(io.ReadAll(dlgs[0].Archive())), 0o644))
+
+		*revoked = nil
+		require.NoError(t, run("delegation", "revoke", file))
+		require.Equal(t, []ipld.Link{dlgs[0].Link()}, *revoked)
+	})
+
+	t.Run("each delegation in the file", func(t *testing.T) {
+		// a CAR file whose roots are both delegations
+		file := path.Join(t.TempDir(), "delegations.car")
+		roots := []ipld.Link{dlgs[0].Link(), dlgs[1].Link()}
+		b := helpers.Must(io.ReadAll(car.Encode(roots, func(yield func(ipld.Block, error) bool) {
+			for _, dlg := range dlgs {
+				for blk, err := range dlg.Blocks() {
+					if !yield(blk, err) {
+						return
+					}
+				}
+			}
+		})))
+		require.NoError(t, os.WriteFile(file, b, 0o644))
+
+		*revoked = nil
+		require.NoError(t, run("delegation", "revoke", file))
+		require.Equal(t, []ipld.Link{dlgs[0].Link(), dlgs[1].Link()}, *revoked)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/storacha/go-w3up/unixfs"
+)
+
+// filesFromPaths collects the files at the paths, walking directories
+// recursively, like the JS CLI. File paths are relative to the deepest
+// directory containing all the files, or just the file name if there is a
+// single file. Unless `hidden` is true, files and directories whose names start
+// with a dot are skipped when walking directories.
+func filesFromPaths(paths []string, hidden bool) ([]unixfs.File, error) {
+	var found []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("resolving path %s: %s", p, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			found = append(found, abs)
+			continue
+		}
+
+		err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != abs && !hidden && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// follow symlinks to files, but not directories to avoid cycles
+			info, err := os.Stat(p)
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading directory %s: %s", p, err)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no files found")
+	}
+
+	base := filepath.Dir(found[0])
+	for _, p := range found[1:] {
+		for base != filepath.Dir(base) && !strings.HasPrefix(p, base+string(filepath.Separator)) {
+			base = filepath.Dir(base)
+		}
+	}
+
+	var files []unixfs.File
+	for _, p := range found {
+		name := filepath.Base(p)
+		if len(found) > 1 {
+			rel, err := filepath.Rel(base, p)
+			if err != nil {
+				return nil, fmt.Errorf("resolving path %s: %s", p, err)
+			}
+			name = filepath.ToSlash(rel)
+		}
+		files = append(files, unixfs.File{
+			Path: name,
+			Open: func() (io.ReadCloser, error) { return os.Open(p) },
+		})
+	}
+	return files, nil
+}
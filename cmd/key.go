@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/storacha/go-w3up/keystore"
+	"github.com/urfave/cli/v2"
+)
+
+const keyFormatUsage = "Key format: multibase, seed (raw 32 byte ed25519 seed), pem (PKCS#8) or json (JS CLI format)."
+
+var keyCommand = &cli.Command{
+	Name:  "key",
+	Usage: "Create, export and import agent keys.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "Generate a new ed25519 key and print it. The key is not used by the agent unless imported.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					Value:   string(keystore.FormatMultibase),
+					Usage:   keyFormatUsage,
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Value:   "",
+					Usage:   "Path of file to write the key to.",
+				},
+			},
+			Action: keyCreate,
+		},
+		{
+			Name:  "export",
+			Usage: "Print the agent key.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					Value:   string(keystore.FormatMultibase),
+					Usage:   keyFormatUsage,
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Value:   "",
+					Usage:   "Path of file to write the key to.",
+				},
+			},
+			Action: keyExport,
+		},
+		{
+			Name:      "import",
+			Usage:     "Replace the agent key with a key read from a file, or stdin if the path is -. The format is detected unless --format is passed.",
+			ArgsUsage: "<path>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					Value:   "",
+					Usage:   keyFormatUsage,
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Value: false,
+					Usage: "Replace the key of a profile that already has one. Delegations to the replaced key can no longer be used.",
+				},
+			},
+			Action: keyImport,
+		},
+	},
+}
+
+func keyCreate(cCtx *cli.Context) error {
+	s, err := signer.Generate()
+	if err != nil {
+		log.Fatalf("generating key: %s", err)
+	}
+	format := mustParseKeyFormat(cCtx.String("format"))
+	out := mustEncodeKey(s, format)
+	// like ucan-key and the JS CLI, include the DID as a comment
+	if format == keystore.FormatMultibase {
+		out = append([]byte(fmt.Sprintf("# %s\n", s.DID())), out...)
+	}
+	mustWriteKey(cCtx.String("output"), out)
+	return nil
+}
+
+func keyExport(cCtx *cli.Context) error {
+	mustWriteKey(cCtx.String("output"), mustEncodeKey(util.MustGetSigner(), mustParseKeyFormat(cCtx.String("format"))))
+	return nil
+}
+
+func keyImport(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing path to key")
+	}
+
+	var b []byte
+	var err error
+	if cCtx.Args().First() == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(cCtx.Args().First())
+	}
+	if err != nil {
+		log.Fatalf("reading key: %s", err)
+	}
+
+	format := keystore.FormatAuto
+	if cCtx.String("format") != "" {
+		format = mustParseKeyFormat(cCtx.String("format"))
+	}
+	s, err := keystore.DecodeKey(b, format)
+	if err != nil {
+		log.Fatalf("decoding key: %s", err)
+	}
+
+	util.MustImportSigner(s, cCtx.Bool("force"))
+	fmt.Println(s.DID())
+	return nil
+}
+
+func mustParseKeyFormat(str string) keystore.Format {
+	var names []string
+	for _, f := range keystore.Formats {
+		if string(f) == str {
+			return f
+		}
+		names = append(names, string(f))
+	}
+	log.Fatalf("unsupported key format %q: expected one of %s", str, strings.Join(names, ", "))
+	return ""
+}
+
+// mustEncodeKey encodes a key, terminating text formats with a newline.
+func mustEncodeKey(s principal.Signer, format keystore.Format) []byte {
+	out, err := keystore.EncodeKey(s, format)
+	if err != nil {
+		log.Fatalf("encoding key: %s", err)
+	}
+	if format == keystore.FormatMultibase || format == keystore.FormatJSON {
+		out = append(out, '\n')
+	}
+	return out
+}
+
+func mustWriteKey(path string, out []byte) {
+	if path != "" {
+		if err := os.WriteFile(path, out, 0600); err != nil {
+			log.Fatalf("writing key: %s", err)
+		}
+		return
+	}
+	if _, err := os.Stdout.Write(out); err != nil {
+		log.Fatalf("writing key: %s", err)
+	}
+}
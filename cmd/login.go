@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/storacha/go-w3up/client"
+	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/urfave/cli/v2"
+)
+
+var loginCommand = &cli.Command{
+	Name:      "login",
+	Usage:     "Authorize this agent to act on behalf of the account with the given email address.",
+	ArgsUsage: "<email>",
+	Action:    login,
+}
+
+func login(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing email address")
+	}
+
+	account, err := client.AccountDID(cCtx.Args().First())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("To authorize this agent, click the link in the email sent to %s\n", cCtx.Args().First())
+
+	dlgs, err := client.Login(
+		cCtx.Context,
+		util.MustGetSigner(),
+		account,
+		client.WithConnection(util.MustGetConnection(cCtx.String("service-url"), cCtx.String("service-did"))),
+		client.WithProofStore(util.MustGetProofStore()),
+	)
+	if err != nil {
+		log.Fatalf("logging in: %s", err)
+	}
+
+	fmt.Printf("Agent authorized by %s (%d delegations stored)\n", account, len(dlgs))
+	return nil
+}
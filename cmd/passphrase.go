@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/urfave/cli/v2"
+)
+
+var passphraseCommand = &cli.Command{
+	Name:  "passphrase",
+	Usage: "Manage the passphrase that encrypts the agent private key at rest.",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "set",
+			Usage:  "Encrypt the agent private key with a passphrase. The new passphrase is read from W3UP_NEW_PASSPHRASE or prompted for.",
+			Action: passphraseSet,
+		},
+		{
+			Name:   "change",
+			Usage:  "Change the passphrase. The current passphrase is read from W3UP_PASSPHRASE and the new one from W3UP_NEW_PASSPHRASE, or they are prompted for.",
+			Action: passphraseChange,
+		},
+		{
+			Name:   "remove",
+			Usage:  "Remove the passphrase, storing the agent private key unencrypted. The current passphrase is read from W3UP_PASSPHRASE or prompted for.",
+			Action: passphraseRemove,
+		},
+	},
+}
+
+func passphraseSet(cCtx *cli.Context) error {
+	util.MustSetPassphrase()
+	fmt.Println("Passphrase set.")
+	return nil
+}
+
+func passphraseChange(cCtx *cli.Context) error {
+	util.MustChangePassphrase()
+	fmt.Println("Passphrase changed.")
+	return nil
+}
+
+func passphraseRemove(cCtx *cli.Context) error {
+	util.MustRemovePassphrase()
+	fmt.Println("Passphrase removed.")
+	return nil
+}
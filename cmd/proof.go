@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/urfave/cli/v2"
+)
+
+var proofCommand = &cli.Command{
+	Name:  "proof",
+	Usage: "Manage proofs (delegations) stored by the agent.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "Add a proof delegated to this agent.",
+			ArgsUsage: "<path>",
+			Action:    proofAdd,
+		},
+		{
+			Name:    "ls",
+			Aliases: []string{"list"},
+			Usage:   "List proofs stored by the agent.",
+			Action:  proofLs,
+		},
+		{
+			Name:      "rm",
+			Aliases:   []string{"remove"},
+			Usage:     "Remove a proof stored by the agent.",
+			ArgsUsage: "<cid>",
+			Action:    proofRm,
+		},
+	},
+}
+
+func proofAdd(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing path to proof")
+	}
+
+	dlgs := util.MustGetProofs(cCtx.Args().First())
+
+	agent := util.MustGetSigner().DID()
+	added := map[string]struct{}{}
+	for _, dlg := range dlgs {
+		if dlg.Audience().DID() != agent {
+			log.Fatalf("proof %s audience %s is not this agent: %s", dlg.Link(), dlg.Audience().DID(), agent)
+		}
+		added[dlg.Link().String()] = struct{}{}
+	}
+
+	store := util.MustGetProofStore()
+	if err := store.Add(dlgs...); err != nil {
+		log.Fatalf("adding proof: %s", err)
+	}
+
+	for _, e := range store.Entries() {
+		if _, ok := added[e.Link.String()]; ok {
+			printEntry(e)
+		}
+	}
+	return nil
+}
+
+func proofLs(cCtx *cli.Context) error {
+	for _, e := range util.MustGetProofStore().Entries() {
+		printEntry(e)
+	}
+	return nil
+}
+
+func proofRm(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing proof CID")
+	}
+
+	c, err := cid.Parse(cCtx.Args().First())
+	if err != nil {
+		log.Fatalf("parsing proof CID: %s", err)
+	}
+
+	if err := util.MustGetProofStore().Remove(cidlink.Link{Cid: c}); err != nil {
+		log.Fatalf("removing proof: %s", err)
+	}
+	return nil
+}
+
+func printEntry(e proof.Entry) {
+	fmt.Println(e.Link)
+	fmt.Printf("\tissuer: %s\n", e.Issuer)
+	fmt.Printf("\taudience: %s\n", e.Audience)
+	if e.Expiration != nil {
+		fmt.Printf("\texpires: %s\n", time.Unix(int64(*e.Expiration), 0).UTC().Format(time.RFC3339))
+	}
+	if e.Revoked != nil {
+		fmt.Printf("\trevoked: %s\n", time.Unix(int64(*e.Revoked), 0).UTC().Format(time.RFC3339))
+	}
+	for _, c := range e.Capabilities {
+		fmt.Printf("\t%s %s\n", c.Can, c.With)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/capability/provideradd"
+	"github.com/storacha/go-w3up/capability/spaceinfo"
+	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/storacha/go-w3up/space"
+	"github.com/urfave/cli/v2"
+)
+
+var spaceCommand = &cli.Command{
+	Name:  "space",
+	Usage: "Create and manage spaces.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "create",
+			Usage:     "Create a new space and delegate full authority over it to this agent.",
+			ArgsUsage: "<name>",
+			Action:    spaceCreate,
+		},
+		{
+			Name:      "recover",
+			Usage:     "Recover access to a space from its recovery mnemonic. The mnemonic is read from stdin if not passed as arguments.",
+			ArgsUsage: "[mnemonic...]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "name",
+					Value: "",
+					Usage: "Name of the recovered space. Defaults to the name the space is known by, if any.",
+				},
+			},
+			Action: spaceRecover,
+		},
+		{
+			Name:    "ls",
+			Aliases: []string{"list"},
+			Usage:   "List spaces known to the agent. The current space is marked with *.",
+			Action:  spaceLs,
+		},
+		{
+			Name:      "use",
+			Usage:     "Set the current space, used by commands when no space is passed.",
+			ArgsUsage: "<name|did>",
+			Action:    spaceUse,
+		},
+		{
+			Name:  "info",
+			Usage: "Show information about a space.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "space",
+					Value: "",
+					Usage: "DID or name of space to show information about. Defaults to the current space.",
+				},
+			},
+			Action: spaceInfo,
+		},
+		{
+			Name:  "provision",
+			Usage: "Provision a space with the service so it can store data. Storage is billed to the account.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "space",
+					Value: "",
+					Usage: "DID or name of space to provision. Defaults to the current space.",
+				},
+				&cli.StringFlag{
+					Name:  "account",
+					Value: "",
+					Usage: "Email or DID of the account to bill. Defaults to the account the agent is logged in to.",
+				},
+			},
+			Action: spaceProvision,
+		},
+	},
+}
+
+func spaceCreate(cCtx *cli.Context) error {
+	name := cCtx.Args().First()
+	if name == "" {
+		log.Fatalf("missing space name")
+	}
+
+	s, err := space.Generate()
+	if err != nil {
+		log.Fatalf("generating space key: %s", err)
+	}
+
+	mnemonic, err := space.ToMnemonic(s)
+	if err != nil {
+		log.Fatalf("creating recovery mnemonic: %s", err)
+	}
+
+	mustStoreSpaceDelegation(s, name)
+	util.MustAddSpace(s.DID(), name)
+
+	fmt.Println(s.DID())
+	fmt.Println()
+	fmt.Println("Recovery mnemonic (keep it secret, it is the only way to recover access to the space):")
+	fmt.Println()
+	fmt.Println(mnemonic)
+	return nil
+}
+
+func spaceRecover(cCtx *cli.Context) error {
+	mnemonic := strings.Join(cCtx.Args().Slice(), " ")
+	if mnemonic == "" {
+		mnemonic = mustReadMnemonic()
+	}
+
+	s, err := space.FromMnemonic(mnemonic)
+	if err != nil {
+		log.Fatalf("recovering space key: %s", err)
+	}
+
+	// keep the name of a space the agent already knows unless a new one is
+	// passed
+	name := cCtx.String("name")
+	if name == "" {
+		for _, known := range util.MustGetSpaces() {
+			if known.DID == s.DID() {
+				name = known.Name
+			}
+		}
+	}
+
+	mustStoreSpaceDelegation(s, name)
+	util.MustAddSpace(s.DID(), name)
+
+	fmt.Println(s.DID())
+	return nil
+}
+
+// mustReadMnemonic reads a space recovery mnemonic from a line of stdin.
+func mustReadMnemonic() string {
+	fmt.Fprintln(os.Stderr, "Enter the recovery mnemonic:")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("reading mnemonic: %s", err)
+	}
+	return line
+}
+
+func spaceLs(cCtx *cli.Context) error {
+	current, _ := util.MustGetCurrentSpace()
+	for _, s := range util.MustGetSpaces() {
+		marker := " "
+		if s.DID == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s %s\n", marker, s.DID, s.Name)
+	}
+	return nil
+}
+
+func spaceUse(cCtx *cli.Context) error {
+	if cCtx.NArg() != 1 {
+		log.Fatalf("missing space name or DID")
+	}
+	s := util.MustResolveSpace(cCtx.Args().First())
+	util.MustSetCurrentSpace(s)
+	fmt.Println(s)
+	return nil
+}
+
+func spaceInfo(cCtx *cli.Context) error {
+	c := mustGetClient(cCtx)
+
+	rcpt, err := c.SpaceInfo(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	info, infoFailure := result.Unwrap(rcpt.Out())
+	if infoFailure != nil {
+		fatalFailure(spaceinfo.Ability, infoFailure)
+	}
+
+	fmt.Printf("DID: %s\n", info.Did)
+	if len(info.Providers) == 0 {
+		fmt.Println("Providers: none")
+	} else {
+		fmt.Printf("Providers: %s\n", strings.Join(info.Providers, ", "))
+	}
+	return nil
+}
+
+func spaceProvision(cCtx *cli.Context) error {
+	c := mustGetClient(cCtx)
+	account := util.MustResolveAccount(cCtx.String("account"))
+
+	rcpt, err := c.Provision(cCtx.Context, account)
+	if err != nil {
+		return err
+	}
+
+	_, provisionFailure := result.Unwrap(rcpt.Out())
+	if provisionFailure != nil {
+		fatalFailure(provideradd.Ability, provisionFailure)
+	}
+
+	fmt.Printf("Provisioned %s with %s\n", c.Space(), c.Connection().ID().DID())
+	return nil
+}
+
+// mustStoreSpaceDelegation delegates full authority over the space to the
+// agent and stores the delegation in the agent proof store.
+func mustStoreSpaceDelegation(s principal.Signer, name string) {
+	dlg, err := space.Delegate(s, util.MustGetSigner(), name)
+	if err != nil {
+		log.Fatalf("delegating space to agent: %s", err)
+	}
+
+	if err := util.MustGetProofStore().Add(dlg); err != nil {
+		log.Fatalf("storing space delegation: %s", err)
+	}
+}
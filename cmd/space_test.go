@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/storacha/go-w3up/space"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpaceRecover(t *testing.T) {
+	t.Setenv("W3UP_CONFIG_DIR", t.TempDir())
+	t.Setenv("W3UP_PRIVATE_KEY", helpers.Must(signer.Format(fixtures.Alice)))
+
+	s := helpers.Must(space.Generate())
+	mnemonic := strings.Fields(helpers.Must(space.ToMnemonic(s)))
+	recoverSpace := func(args ...string) {
+		args = append([]string{"w3", "space", "recover"}, args...)
+		require.NoError(t, newApp().RunContext(context.Background(), append(args, mnemonic...)))
+	}
+	name := func() string {
+		for _, known := range util.MustGetSpaces() {
+			if known.DID == s.DID() {
+				return known.Name
+			}
+		}
+		t.Fatalf("space %s is not known", s.DID())
+		return ""
+	}
+
+	recoverSpace("--name", "photos")
+	require.Equal(t, "photos", name())
+
+	t.Run("keeps name", func(t *testing.T) {
+		recoverSpace()
+		require.Equal(t, "photos", name())
+	})
+
+	t.Run("renames", func(t *testing.T) {
+		recoverSpace("--name", "videos")
+		require.Equal(t, "videos", name())
+	})
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"log"
+	"strings"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-w3up/client"
+)
+
+// MustGetAccounts returns the accounts the agent is logged in to. These are
+// the `did:mailto:` issuers of delegations to the agent in the proof store.
+func MustGetAccounts() []did.DID {
+	agent := MustGetSigner().DID().String()
+
+	var accounts []did.DID
+	seen := map[string]struct{}{}
+	for _, e := range MustGetProofStore().Entries() {
+		if e.Audience != agent || !strings.HasPrefix(e.Issuer, "did:mailto:") {
+			continue
+		}
+		if _, ok := seen[e.Issuer]; ok {
+			continue
+		}
+		accounts = append(accounts, MustParseDID(e.Issuer))
+		seen[e.Issuer] = struct{}{}
+	}
+	return accounts
+}
+
+// MustResolveAccount returns the account identified by an email address or DID.
+// If none is passed, the account the agent is logged in to is returned.
+func MustResolveAccount(emailOrDID string) did.DID {
+	if emailOrDID != "" {
+		if strings.HasPrefix(emailOrDID, "did:") {
+			return MustParseDID(emailOrDID)
+		}
+		account, err := client.AccountDID(emailOrDID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return account
+	}
+
+	accounts := MustGetAccounts()
+	if len(accounts) == 0 {
+		log.Fatalf("not logged in: run `w3 login <email>`")
+	}
+	if len(accounts) > 1 {
+		log.Fatalf("logged in to multiple accounts, pass --account")
+	}
+	return accounts[0]
+}
@@ -0,0 +1,244 @@
+package util
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"regexp"
+
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-w3up/keystore"
+)
+
+//go:embed config.ipldsch
+var configsch []byte
+
+// DefaultProfile is the profile used when none is selected. Its config is
+// stored at the root of the config directory, where configs were stored
+// before profiles were introduced.
+const DefaultProfile = "default"
+
+// configVersion is the version of the config schema in `config.ipldsch`.
+const configVersion = 1
+
+// migrations upgrade a config from the version at their index to the next
+// version. The version field itself is updated by `mustMigrateConfig`.
+var migrations = []func(datamodel.Node) (datamodel.Node, error){
+	// 0 -> 1: configs were unversioned
+	func(n datamodel.Node) (datamodel.Node, error) { return n, nil },
+}
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// profile is the profile whose config and proofs are used.
+var profile = DefaultProfile
+
+type configurationModel struct {
+	// Version is the version of the config schema, see `configVersion`.
+	Version int64
+	// Signer is the encoded signer of the agent, unless it is encrypted.
+	Signer []byte
+	// EncryptedSigner is the encoded signer of the agent encrypted with a
+	// passphrase, see `MustSetPassphrase`.
+	EncryptedSigner *keystore.Sealed
+	// Space is the DID of the current space.
+	Space *string
+	// Spaces maps the DIDs of spaces created or recovered by the agent to their
+	// names.
+	Spaces *spacesModel
+}
+
+type spacesModel struct {
+	Keys   []string
+	Values map[string]string
+}
+
+// MustSetProfile selects the profile whose config and proofs are used. Each
+// profile has its own agent signer, spaces and proofs.
+func MustSetProfile(name string) {
+	if !profileName.MatchString(name) {
+		log.Fatalf("invalid profile name %q: must contain only letters, digits, '.', '_' and '-'", name)
+	}
+	profile = name
+}
+
+func mustLoadConfigSchema() *schema.TypeSystem {
+	ts, err := ipld.LoadSchemaBytes(configsch)
+	if err != nil {
+		log.Fatalf("failed to load IPLD schema: %s", err)
+	}
+	return ts
+}
+
+// mustGetConfigDir returns the directory configs are stored in. It is
+// `W3UP_CONFIG_DIR` if set. Otherwise it is `w3up` in the XDG config directory
+// (`$XDG_CONFIG_HOME` or `~/.config`), unless only the legacy `~/.w3up`
+// directory exists.
+func mustGetConfigDir() string {
+	if dir := os.Getenv("W3UP_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("obtaining user home directory: %s", err)
+	}
+	xdg := path.Join(homedir, ".config", "w3up")
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		xdg = path.Join(dir, "w3up")
+	}
+	if _, err := os.Stat(xdg); err == nil {
+		return xdg
+	}
+	legacy := path.Join(homedir, ".w3up")
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	return xdg
+}
+
+// mustGetProfileDir returns the directory the config and proofs of the
+// selected profile are stored in.
+func mustGetProfileDir() string {
+	if profile == DefaultProfile {
+		return mustGetConfigDir()
+	}
+	return path.Join(mustGetConfigDir(), "profiles", profile)
+}
+
+func mustGetConfigPath() string {
+	return path.Join(mustGetProfileDir(), "config")
+}
+
+func mustReadConfig() *configurationModel {
+	typ := mustLoadConfigSchema().TypeByName("Configuration")
+	conf := configurationModel{Version: configVersion}
+
+	bytes, err := os.ReadFile(mustGetConfigPath())
+	if err != nil {
+		s, err := signer.Generate()
+		if err != nil {
+			log.Fatalf("generating signer: %s", err)
+		}
+
+		conf.Signer = s.Encode()
+		// encrypt new signers when a passphrase is configured
+		if passphrase := os.Getenv(passphraseEnvVar); passphrase != "" {
+			conf.Signer = nil
+			conf.EncryptedSigner = mustSeal(s.Encode(), []byte(passphrase))
+		}
+		mustWriteConfig(&conf)
+	} else {
+		bytes = mustMigrateConfig(bytes)
+		_, err = ipld.Unmarshal(bytes, dagcbor.Decode, &conf, typ)
+		if err != nil {
+			log.Fatalf("decoding config: %s", err)
+		}
+	}
+
+	return &conf
+}
+
+func mustWriteConfig(conf *configurationModel) {
+	typ := mustLoadConfigSchema().TypeByName("Configuration")
+	conf.Version = configVersion
+	bytes, err := ipld.Marshal(dagcbor.Encode, conf, typ)
+	if err != nil {
+		log.Fatalf("encoding config: %s", err)
+	}
+	if err := os.MkdirAll(mustGetProfileDir(), 0700); err != nil {
+		log.Fatalf("writing config: %s", err)
+	}
+	if err := os.WriteFile(mustGetConfigPath(), bytes, 0600); err != nil {
+		log.Fatalf("writing config: %s", err)
+	}
+}
+
+// mustMigrateConfig upgrades an encoded config written with an older version
+// of the schema to the current version. The migrated config is written back,
+// keeping a copy of the original with the old version number as suffix.
+func mustMigrateConfig(bytes []byte) []byte {
+	n, err := ipld.Decode(bytes, dagcbor.Decode)
+	if err != nil {
+		log.Fatalf("decoding config: %s", err)
+	}
+	version, err := readConfigVersion(n)
+	if err != nil {
+		log.Fatalf("decoding config: %s", err)
+	}
+	if version > configVersion {
+		log.Fatalf("config version %d is newer than the supported version %d: upgrade w3", version, configVersion)
+	}
+	if version == configVersion {
+		return bytes
+	}
+
+	for v := version; v < configVersion; v++ {
+		n, err = migrations[v](n)
+		if err != nil {
+			log.Fatalf("migrating config from version %d: %s", v, err)
+		}
+	}
+	n, err = setConfigVersion(n, configVersion)
+	if err != nil {
+		log.Fatalf("migrating config: %s", err)
+	}
+	migrated, err := ipld.Encode(n, dagcbor.Encode)
+	if err != nil {
+		log.Fatalf("encoding config: %s", err)
+	}
+
+	backup := fmt.Sprintf("%s.v%d", mustGetConfigPath(), version)
+	if err := os.WriteFile(backup, bytes, 0600); err != nil {
+		log.Fatalf("backing up config: %s", err)
+	}
+	if err := os.WriteFile(mustGetConfigPath(), migrated, 0600); err != nil {
+		log.Fatalf("writing config: %s", err)
+	}
+	return migrated
+}
+
+// readConfigVersion returns the version of an encoded config, which is 0 for
+// configs written before the schema was versioned.
+func readConfigVersion(n datamodel.Node) (int64, error) {
+	if n.Kind() != datamodel.Kind_Map {
+		return 0, fmt.Errorf("config is a %s, expected a map", n.Kind())
+	}
+	v, err := n.LookupByString("Version")
+	if err != nil {
+		if _, ok := err.(datamodel.ErrNotExists); ok {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return v.AsInt()
+}
+
+func setConfigVersion(n datamodel.Node, version int64) (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, n.Length()+1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "Version", qp.Int(version))
+		it := n.MapIterator()
+		for !it.Done() {
+			k, v, err := it.Next()
+			if err != nil {
+				panic(err)
+			}
+			key, err := k.AsString()
+			if err != nil {
+				panic(err)
+			}
+			if key == "Version" {
+				continue
+			}
+			qp.MapEntry(ma, key, qp.Node(v))
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"log"
+	"os"
+
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/keystore"
+)
+
+// MustImportSigner replaces the signer of the agent in the selected profile.
+// Unless `force` is true, it fails if the profile already has a config, since
+// delegations to the current signer can no longer be used once it is replaced.
+// If the current signer is encrypted, the imported signer is encrypted with
+// the same passphrase. The signer of a new profile is encrypted if
+// `W3UP_PASSPHRASE` is set.
+func MustImportSigner(s principal.Signer, force bool) {
+	var conf *configurationModel
+	if _, err := os.Stat(mustGetConfigPath()); err == nil {
+		if !force {
+			log.Fatalf("profile %q already has an agent key: pass --force to replace it", profile)
+		}
+		conf = mustReadConfig()
+	} else {
+		conf = &configurationModel{}
+	}
+
+	switch {
+	case conf.EncryptedSigner != nil:
+		passphrase := mustGetPassphrase()
+		if _, err := keystore.Open(conf.EncryptedSigner, passphrase); err != nil {
+			log.Fatalf("unlocking signer: %s", err)
+		}
+		conf.EncryptedSigner = mustSeal(s.Encode(), passphrase)
+	case len(conf.Signer) == 0 && os.Getenv(passphraseEnvVar) != "":
+		conf.EncryptedSigner = mustSeal(s.Encode(), []byte(os.Getenv(passphraseEnvVar)))
+	default:
+		conf.Signer = s.Encode()
+	}
+	mustWriteConfig(conf)
+	unlocked = s
+}
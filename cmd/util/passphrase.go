@@ -0,0 +1,127 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-w3up/keystore"
+	"golang.org/x/term"
+)
+
+const (
+	// passphraseEnvVar is the environment variable holding the passphrase that
+	// unlocks the agent signer.
+	passphraseEnvVar = "W3UP_PASSPHRASE"
+	// newPassphraseEnvVar is the environment variable holding the passphrase set
+	// by `MustSetPassphrase` and `MustChangePassphrase`.
+	newPassphraseEnvVar = "W3UP_NEW_PASSPHRASE"
+)
+
+// unlocked is the agent signer once it has been read from the config.
+var unlocked principal.Signer
+
+// MustSetPassphrase encrypts the agent signer with a passphrase. This migrates
+// a config that stores the signer in plaintext. The passphrase is read from
+// `W3UP_NEW_PASSPHRASE` or prompted for.
+func MustSetPassphrase() {
+	conf := mustReadConfig()
+	if conf.EncryptedSigner != nil {
+		log.Fatalf("passphrase is already set: use `w3 passphrase change` to change it")
+	}
+	conf.EncryptedSigner = mustSeal(conf.Signer, mustGetNewPassphrase())
+	conf.Signer = nil
+	mustWriteConfig(conf)
+}
+
+// MustChangePassphrase encrypts the agent signer with a new passphrase. The
+// current passphrase is read from `W3UP_PASSPHRASE` or prompted for, and the
+// new one from `W3UP_NEW_PASSPHRASE` or prompted for.
+func MustChangePassphrase() {
+	conf := mustReadConfig()
+	if conf.EncryptedSigner == nil {
+		log.Fatalf("no passphrase is set: use `w3 passphrase set` to set one")
+	}
+	conf.EncryptedSigner = mustSeal(mustGetSignerBytes(conf), mustGetNewPassphrase())
+	mustWriteConfig(conf)
+}
+
+// MustRemovePassphrase decrypts the agent signer, storing it in plaintext. The
+// current passphrase is read from `W3UP_PASSPHRASE` or prompted for.
+func MustRemovePassphrase() {
+	conf := mustReadConfig()
+	if conf.EncryptedSigner == nil {
+		log.Fatalf("no passphrase is set")
+	}
+	conf.Signer = mustGetSignerBytes(conf)
+	conf.EncryptedSigner = nil
+	mustWriteConfig(conf)
+}
+
+// mustGetSignerBytes returns the encoded agent signer, decrypting it if it is
+// encrypted.
+func mustGetSignerBytes(conf *configurationModel) []byte {
+	if conf.EncryptedSigner == nil {
+		if len(conf.Signer) == 0 {
+			log.Fatalf("config has no signer: %s", mustGetConfigPath())
+		}
+		return conf.Signer
+	}
+
+	b, err := keystore.Open(conf.EncryptedSigner, mustGetPassphrase())
+	if err != nil {
+		log.Fatalf("unlocking signer: %s", err)
+	}
+	return b
+}
+
+func mustSeal(plaintext []byte, passphrase []byte) *keystore.Sealed {
+	sealed, err := keystore.Seal(plaintext, passphrase)
+	if err != nil {
+		log.Fatalf("encrypting signer: %s", err)
+	}
+	return sealed
+}
+
+// mustGetPassphrase returns the passphrase that unlocks the agent signer.
+func mustGetPassphrase() []byte {
+	if passphrase := os.Getenv(passphraseEnvVar); passphrase != "" {
+		return []byte(passphrase)
+	}
+	return mustPrompt("Passphrase: ", passphraseEnvVar)
+}
+
+// mustGetNewPassphrase returns a new passphrase for the agent signer, which
+// must be entered twice when prompted for.
+func mustGetNewPassphrase() []byte {
+	if passphrase := os.Getenv(newPassphraseEnvVar); passphrase != "" {
+		return []byte(passphrase)
+	}
+	passphrase := mustPrompt("New passphrase: ", newPassphraseEnvVar)
+	if len(passphrase) == 0 {
+		log.Fatalf("passphrase must not be empty")
+	}
+	if !bytes.Equal(passphrase, mustPrompt("Confirm new passphrase: ", newPassphraseEnvVar)) {
+		log.Fatalf("passphrases do not match")
+	}
+	return passphrase
+}
+
+// mustPrompt reads a passphrase from the terminal without echoing it. It fails
+// if stdin is not a terminal, in which case the passphrase must be passed in the
+// environment variable.
+func mustPrompt(prompt string, envVar string) []byte {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		log.Fatalf("cannot prompt for passphrase: stdin is not a terminal, set %s", envVar)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		log.Fatalf("reading passphrase: %s", err)
+	}
+	return passphrase
+}
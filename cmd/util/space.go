@@ -0,0 +1,103 @@
+package util
+
+import (
+	"log"
+	"strings"
+
+	"github.com/storacha/go-ucanto/did"
+)
+
+// Space is a space known to the agent.
+type Space struct {
+	DID  did.DID
+	Name string
+}
+
+// MustGetSpaces returns the spaces known to the agent. These are the spaces
+// created or recovered by the agent, followed by the resources of any other
+// delegations to the agent in the proof store that are `did:key` DIDs.
+func MustGetSpaces() []Space {
+	conf := mustReadConfig()
+	agent := MustGetSigner().DID().String()
+
+	var spaces []Space
+	seen := map[string]struct{}{}
+	if conf.Spaces != nil {
+		for _, k := range conf.Spaces.Keys {
+			spaces = append(spaces, Space{DID: MustParseDID(k), Name: conf.Spaces.Values[k]})
+			seen[k] = struct{}{}
+		}
+	}
+
+	for _, e := range MustGetProofStore().Entries() {
+		if e.Audience != agent {
+			continue
+		}
+		for _, c := range e.Capabilities {
+			if !strings.HasPrefix(c.With, "did:key:") || c.With == agent {
+				continue
+			}
+			if _, ok := seen[c.With]; ok {
+				continue
+			}
+			spaces = append(spaces, Space{DID: MustParseDID(c.With)})
+			seen[c.With] = struct{}{}
+		}
+	}
+
+	return spaces
+}
+
+// MustAddSpace records a space created or recovered by the agent and makes it
+// the current space.
+func MustAddSpace(space did.DID, name string) {
+	conf := mustReadConfig()
+	if conf.Spaces == nil {
+		conf.Spaces = &spacesModel{Values: map[string]string{}}
+	}
+	key := space.String()
+	if _, ok := conf.Spaces.Values[key]; !ok {
+		conf.Spaces.Keys = append(conf.Spaces.Keys, key)
+	}
+	conf.Spaces.Values[key] = name
+	conf.Space = &key
+	mustWriteConfig(conf)
+}
+
+// MustSetCurrentSpace sets the space used by commands when none is passed.
+func MustSetCurrentSpace(space did.DID) {
+	conf := mustReadConfig()
+	key := space.String()
+	conf.Space = &key
+	mustWriteConfig(conf)
+}
+
+// MustGetCurrentSpace returns the space used by commands when none is passed.
+// It returns false if no space has been set.
+func MustGetCurrentSpace() (did.DID, bool) {
+	conf := mustReadConfig()
+	if conf.Space == nil {
+		return did.DID{}, false
+	}
+	return MustParseDID(*conf.Space), true
+}
+
+// MustResolveSpace finds a space known to the agent by name or DID.
+func MustResolveSpace(nameOrDID string) did.DID {
+	var matches []Space
+	for _, s := range MustGetSpaces() {
+		if s.DID.String() == nameOrDID || s.Name == nameOrDID {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) == 0 {
+		if strings.HasPrefix(nameOrDID, "did:") {
+			return MustParseDID(nameOrDID)
+		}
+		log.Fatalf("space not found: %s", nameOrDID)
+	}
+	if len(matches) > 1 {
+		log.Fatalf("multiple spaces named %q, use the space DID instead", nameOrDID)
+	}
+	return matches[0].DID
+}
@@ -1,32 +1,23 @@
 package util
 
 import (
-	_ "embed"
 	"log"
 	"net/url"
 	"os"
 	"path"
 
-	"github.com/ipld/go-ipld-prime"
-	"github.com/ipld/go-ipld-prime/codec/dagcbor"
-	"github.com/ipld/go-ipld-prime/schema"
 	"github.com/storacha/go-ucanto/client"
 	"github.com/storacha/go-ucanto/core/delegation"
 	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal"
 	"github.com/storacha/go-ucanto/principal/ed25519/signer"
-	"github.com/storacha/go-ucanto/transport/car"
-	"github.com/storacha/go-ucanto/transport/http"
+	w3client "github.com/storacha/go-w3up/client"
 	cdg "github.com/storacha/go-w3up/delegation"
+	"github.com/storacha/go-w3up/proof"
 )
 
-//go:embed config.ipldsch
-var configsch []byte
-
-type configurationModel struct {
-	Signer []byte
-}
-
+// MustGetSigner returns the signer of the agent. If it is encrypted, the
+// passphrase is read from `W3UP_PASSPHRASE` or prompted for.
 func MustGetSigner() principal.Signer {
 	str := os.Getenv("W3UP_PRIVATE_KEY") // use env var preferably
 	if str != "" {
@@ -37,78 +28,29 @@ func MustGetSigner() principal.Signer {
 		return s
 	}
 
+	// avoid prompting for the passphrase more than once
+	if unlocked != nil {
+		return unlocked
+	}
+
 	conf := mustReadConfig()
-	s, err := signer.Decode(conf.Signer)
+	s, err := signer.Decode(mustGetSignerBytes(conf))
 	if err != nil {
 		log.Fatalf("decoding signer: %s", err)
 	}
+	unlocked = s
 	return s
 }
 
-func mustLoadConfigSchema() *schema.TypeSystem {
-	ts, err := ipld.LoadSchemaBytes(configsch)
+// MustGetConnection creates a connection to the service at the passed URL,
+// identified by the passed DID.
+func MustGetConnection(serviceURL string, serviceDID string) client.Connection {
+	u, err := url.Parse(serviceURL)
 	if err != nil {
-		log.Fatalf("failed to load IPLD schema: %s", err)
+		log.Fatalf("parsing service URL: %s", err)
 	}
-	return ts
-}
-
-func mustReadConfig() *configurationModel {
-	homedir, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("obtaining user home directory: %s", err)
-	}
-
-	typ := mustLoadConfigSchema().TypeByName("Configuration")
-	confdir := path.Join(homedir, ".w3up")
-	confpath := path.Join(confdir, "config")
-	conf := configurationModel{}
 
-	bytes, err := os.ReadFile(confpath)
-	if err != nil {
-		s, err := signer.Generate()
-		if err != nil {
-			log.Fatalf("generating signer: %s", err)
-		}
-
-		conf.Signer = s.Encode()
-		bytes, err = ipld.Marshal(dagcbor.Encode, &conf, typ)
-		if err != nil {
-			log.Fatalf("encoding config: %s", err)
-		}
-		if err := os.Mkdir(confdir, 0700); err != nil {
-			log.Fatalf("writing config: %s", err)
-		}
-		if os.WriteFile(confpath, bytes, 0600); err != nil {
-			log.Fatalf("writing config: %s", err)
-		}
-	} else {
-		_, err = ipld.Unmarshal(bytes, dagcbor.Decode, &conf, typ)
-		if err != nil {
-			log.Fatalf("decoding config: %s", err)
-		}
-	}
-
-	return &conf
-}
-
-func MustGetConnection() client.Connection {
-	// service URL & DID
-	serviceURL, err := url.Parse("https://up.web3.storage")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	servicePrincipal, err := did.Parse("did:web:web3.storage")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// HTTP transport and CAR encoding
-	channel := http.NewHTTPChannel(serviceURL)
-	codec := car.NewCAROutboundCodec()
-
-	conn, err := client.NewConnection(servicePrincipal, channel, client.WithOutboundCodec(codec))
+	conn, err := w3client.NewConnection(u, MustParseDID(serviceDID))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -124,15 +66,26 @@ func MustParseDID(str string) did.DID {
 	return did
 }
 
-func MustGetProof(path string) delegation.Delegation {
+// MustGetProofStore opens the store of proofs (delegations) for the agent.
+func MustGetProofStore() *proof.FSStore {
+	store, err := proof.NewFSStore(path.Join(mustGetProfileDir(), "proofs"))
+	if err != nil {
+		log.Fatalf("opening proof store: %s", err)
+	}
+	return store
+}
+
+// MustGetProofs reads the delegations from a proof file, in any of the
+// encodings supported by `delegation.ExtractProofs`.
+func MustGetProofs(path string) []delegation.Delegation {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		log.Fatalf("reading proof file: %s", err)
 	}
 
-	proof, err := cdg.ExtractProof(b)
+	proofs, err := cdg.ExtractProofs(b)
 	if err != nil {
 		log.Fatal(err)
 	}
-	return proof
+	return proofs
 }
@@ -1,35 +1,74 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/ipfs/go-cid"
 	"github.com/ipld/go-ipld-prime"
-	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
-	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-ucanto/core/car"
-	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld/block"
 	"github.com/storacha/go-ucanto/core/result"
 	"github.com/storacha/go-ucanto/did"
-	"github.com/storacha/go-ucanto/principal"
-	"github.com/storacha/go-w3up/capability/storeadd"
+	"github.com/storacha/go-w3up/capability/blobaccept"
+	"github.com/storacha/go-w3up/capability/blobadd"
+	"github.com/storacha/go-w3up/capability/bloballocate"
+	"github.com/storacha/go-w3up/capability/failure"
+	"github.com/storacha/go-w3up/capability/ucanconclude"
 	"github.com/storacha/go-w3up/capability/uploadadd"
 	"github.com/storacha/go-w3up/capability/uploadlist"
 	"github.com/storacha/go-w3up/car/sharding"
 	"github.com/storacha/go-w3up/client"
 	"github.com/storacha/go-w3up/cmd/util"
+	"github.com/storacha/go-w3up/unixfs"
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
-	app := &cli.App{
+	// cancel in-flight requests on shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := newApp().RunContext(ctx, os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newApp creates the w3 command line app.
+func newApp() *cli.App {
+	return &cli.App{
 		Name:  "w3",
 		Usage: "interact with the web3.storage API",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service-url",
+				Value:   client.DefaultServiceURL,
+				Usage:   "URL of the service to interact with.",
+				EnvVars: []string{"W3UP_SERVICE_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "service-did",
+				Value:   client.DefaultServiceDID,
+				Usage:   "DID of the service to interact with.",
+				EnvVars: []string{"W3UP_SERVICE_DID"},
+			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Value:   util.DefaultProfile,
+				Usage:   "Name of the agent profile to use. Each profile has its own identity, spaces and proofs.",
+				EnvVars: []string{"W3UP_PROFILE"},
+			},
+		},
+		Before: func(cCtx *cli.Context) error {
+			util.MustSetProfile(cCtx.String("profile"))
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:   "whoami",
@@ -37,19 +76,20 @@ func main() {
 				Action: whoami,
 			},
 			{
-				Name:    "up",
-				Aliases: []string{"upload"},
-				Usage:   "Store a file(s) to the service and register an upload.",
+				Name:      "up",
+				Aliases:   []string{"upload"},
+				Usage:     "Store a file(s) to the service and register an upload.",
+				ArgsUsage: "<path...>",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "space",
 						Value: "",
-						Usage: "DID of space to upload to.",
+						Usage: "DID or name of space to upload to. Defaults to the current space.",
 					},
 					&cli.StringFlag{
 						Name:  "proof",
 						Value: "",
-						Usage: "Path to file containing UCAN proof(s) for the operation.",
+						Usage: "Path to file containing UCAN proof(s) for the operation, in addition to stored proofs.",
 					},
 					&cli.StringFlag{
 						Name:    "car",
@@ -57,6 +97,21 @@ func main() {
 						Value:   "",
 						Usage:   "Path to CAR file to upload.",
 					},
+					&cli.BoolFlag{
+						Name:  "wrap",
+						Value: true,
+						Usage: "Wrap a single input file in a directory. Has no effect on directory or CAR uploads.",
+					},
+					&cli.BoolFlag{
+						Name:  "no-wrap",
+						Value: false,
+						Usage: "Do not wrap a single input file in a directory.",
+					},
+					&cli.BoolFlag{
+						Name:  "hidden",
+						Value: false,
+						Usage: "Include paths that start with \".\".",
+					},
 				},
 				Action: up,
 			},
@@ -68,12 +123,12 @@ func main() {
 					&cli.StringFlag{
 						Name:  "space",
 						Value: "",
-						Usage: "DID of space to list uploads from.",
+						Usage: "DID or name of space to list uploads from. Defaults to the current space.",
 					},
 					&cli.StringFlag{
 						Name:  "proof",
 						Value: "",
-						Usage: "Path to file containing UCAN proof(s) for the operation.",
+						Usage: "Path to file containing UCAN proof(s) for the operation, in addition to stored proofs.",
 					},
 					&cli.BoolFlag{
 						Name:  "shards",
@@ -83,12 +138,14 @@ func main() {
 				},
 				Action: ls,
 			},
+			delegationCommand,
+			keyCommand,
+			loginCommand,
+			passphraseCommand,
+			proofCommand,
+			spaceCommand,
 		},
 	}
-
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
-	}
 }
 
 func whoami(cCtx *cli.Context) error {
@@ -97,176 +154,288 @@ func whoami(cCtx *cli.Context) error {
 	return nil
 }
 
+// mustGetClient creates a client for the agent, configured with the space and
+// proof passed as command flags. Proofs are also selected from the agent proof
+// store.
+func mustGetClient(cCtx *cli.Context) *client.Client {
+	options := []client.Option{
+		client.WithConnection(util.MustGetConnection(cCtx.String("service-url"), cCtx.String("service-did"))),
+		client.WithProofStore(util.MustGetProofStore()),
+		client.WithSpace(mustGetSpace(cCtx)),
+	}
+	if cCtx.String("proof") != "" {
+		options = append(options, client.WithProofs(util.MustGetProofs(cCtx.String("proof"))))
+	}
+
+	c, err := client.NewClient(util.MustGetSigner(), options...)
+	if err != nil {
+		log.Fatalf("creating client: %s", err)
+	}
+	return c
+}
+
+// mustGetSpace returns the space passed as a command flag, or the current space
+// if none was passed.
+func mustGetSpace(cCtx *cli.Context) did.DID {
+	if cCtx.String("space") != "" {
+		return util.MustResolveSpace(cCtx.String("space"))
+	}
+	space, ok := util.MustGetCurrentSpace()
+	if !ok {
+		log.Fatalf("no space selected: pass --space or run `w3 space use`")
+	}
+	return space
+}
+
 func up(cCtx *cli.Context) error {
-	signer := util.MustGetSigner()
-	conn := util.MustGetConnection()
-	space := util.MustParseDID(cCtx.String("space"))
-	proofs := []delegation.Delegation{util.MustGetProof(cCtx.String("proof"))}
+	c := mustGetClient(cCtx)
 
-	f0, err := os.Open(cCtx.String("car"))
+	var root ipld.Link
+	var shdlnks []ipld.Link
+	var err error
+	if cCtx.String("car") != "" {
+		root, shdlnks, err = upCAR(cCtx, c)
+	} else {
+		root, shdlnks, err = upFiles(cCtx, c)
+	}
 	if err != nil {
-		log.Fatalf("opening file: %s", err)
+		return err
 	}
 
-	stat, err := f0.Stat()
+	if root != nil {
+		rcpt, err := c.UploadAdd(cCtx.Context, uploadadd.Caveat{
+			Root:   root,
+			Shards: shdlnks,
+		})
+		if err != nil {
+			return contextError(cCtx.Context, err)
+		}
+
+		_, upFailure := result.Unwrap(rcpt.Out())
+		if upFailure != nil {
+			return failureError(uploadadd.Ability, upFailure)
+		}
+
+		fmt.Printf("⁂ https://w3s.link/ipfs/%s\n", root)
+	}
+
+	return nil
+}
+
+// upCAR stores the CAR file passed as a command flag, sharding it if it is too
+// big. The CAR is decoded once: a file small enough to be a single shard is
+// hashed and then stored as is, otherwise its blocks are streamed into shards.
+// It returns the first root of the CAR, if any, and the shard links.
+func upCAR(cCtx *cli.Context, c *client.Client) (ipld.Link, []ipld.Link, error) {
+	f, err := os.Open(cCtx.String("car"))
 	if err != nil {
-		log.Fatalf("stat file: %s", err)
+		return nil, nil, fmt.Errorf("opening file: %w", err)
 	}
+	defer f.Close()
 
-	var shdlnks []ipld.Link
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat file: %w", err)
+	}
 
-	defer f0.Close()
+	roots, blocks, err := car.Decode(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding CAR: %w", err)
+	}
+
+	var shdlnks []ipld.Link
 	if stat.Size() < sharding.ShardSize {
-		link := storeShard(signer, space, f0, proofs)
+		shd, err := sharding.NewShard(f, stat.Size())
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading CAR: %w", err)
+		}
+		link, err := storeShard(cCtx.Context, c, shd)
+		if err != nil {
+			return nil, nil, contextError(cCtx.Context, err)
+		}
 		fmt.Println(link.String())
 		shdlnks = append(shdlnks, link)
 	} else {
-		_, blocks, err := car.Decode(f0)
-		if err != nil {
-			log.Fatalf("decoding CAR: %s", err)
-		}
-		shds, err := sharding.NewSharder([]ipld.Link{}, blocks)
+		shdlnks, err = storeShards(cCtx.Context, c, blocks)
 		if err != nil {
-			log.Fatalf("sharding CAR: %s", err)
+			return nil, nil, err
 		}
+	}
 
-		for shd, err := range shds {
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				log.Fatal(err)
-			}
-			link := storeShard(signer, space, shd, proofs)
-			fmt.Println(link.String())
-			shdlnks = append(shdlnks, link)
-		}
+	if len(roots) == 0 {
+		return nil, shdlnks, nil
 	}
+	return roots[0], shdlnks, nil
+}
 
-	f3, err := os.Open(cCtx.String("car"))
-	if err != nil {
-		log.Fatalf("opening file: %s", err)
+// upFiles encodes the files and directories passed as arguments as UnixFS and
+// stores the blocks in shards. Like the JS CLI, a single file is wrapped in a
+// directory unless --no-wrap is passed. It returns the UnixFS root and the
+// shard links.
+func upFiles(cCtx *cli.Context, c *client.Client) (ipld.Link, []ipld.Link, error) {
+	if cCtx.NArg() == 0 {
+		return nil, nil, fmt.Errorf("missing paths to upload: pass <path...> or --car")
 	}
-	roots, _, err := car.Decode(f3)
+
+	files, err := filesFromPaths(cCtx.Args().Slice(), cCtx.Bool("hidden"))
 	if err != nil {
-		log.Fatalf("reading roots: %s", err)
+		return nil, nil, fmt.Errorf("reading files: %w", err)
 	}
-	err = f3.Close()
-	if err != nil {
-		log.Fatalf("closing file: %s", err)
-	}
-	if len(roots) > 0 {
-		rcpt, err := client.UploadAdd(
-			signer,
-			space,
-			uploadadd.Caveat{
-				Root:   roots[0],
-				Shards: shdlnks,
-			},
-			client.WithConnection(conn),
-			client.WithProofs(proofs),
-		)
+
+	var blocks iter.Seq2[block.Block, error]
+	if len(files) == 1 && (cCtx.Bool("no-wrap") || !cCtx.Bool("wrap")) {
+		f, err := files[0].Open()
 		if err != nil {
-			return err
+			return nil, nil, fmt.Errorf("opening file: %w", err)
 		}
+		defer f.Close()
+		blocks = unixfs.EncodeFile(f)
+	} else {
+		blocks = unixfs.EncodeDirectory(files)
+	}
 
-		_, upFailure := result.Unwrap(rcpt.Out())
-		if upFailure != nil {
-			log.Fatalf("%+v\n", upFailure)
+	// the root is the last block
+	var root ipld.Link
+	shdlnks, err := storeShards(cCtx.Context, c, func(yield func(block.Block, error) bool) {
+		for blk, err := range blocks {
+			if err == nil {
+				root = blk.Link()
+			}
+			if !yield(blk, err) {
+				return
+			}
 		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, shdlnks, nil
+}
 
-		fmt.Printf("⁂ https://w3s.link/ipfs/%s\n", roots[0])
+// storeShards stores the blocks in shards of up to `sharding.ShardSize` bytes,
+// returning the shard links. Each shard is spooled to a temporary file as it is
+// produced, so only one shard is on disk at a time and none is held in memory.
+func storeShards(ctx context.Context, c *client.Client, blocks iter.Seq2[block.Block, error]) ([]ipld.Link, error) {
+	shds, err := sharding.NewSharder([]ipld.Link{}, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("sharding CAR: %w", err)
 	}
 
-	return nil
+	var shdlnks []ipld.Link
+	for shd, err := range shds {
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		spooled, err := sharding.Spool(shd, "")
+		if err != nil {
+			return nil, fmt.Errorf("reading shard: %w", err)
+		}
+		link, err := storeShard(ctx, c, spooled)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		fmt.Println(link.String())
+		shdlnks = append(shdlnks, link)
+	}
+	return shdlnks, nil
 }
 
-func storeShard(issuer principal.Signer, space did.DID, shard io.Reader, proofs []delegation.Delegation) ipld.Link {
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(shard)
-	if err != nil {
-		log.Fatalf("reading CAR: %s", err)
+// contextError returns the error of the context once it is done, in place of
+// the error of the request it interrupted, so that an interrupted upload is
+// reported as such.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
+	return err
+}
 
-	mh, err := multihash.Sum(buf.Bytes(), multihash.SHA2_256, -1)
+// storeShard stores a hashed shard and closes it, whether or not it could be
+// stored.
+func storeShard(ctx context.Context, c *client.Client, shard *sharding.Shard) (ipld.Link, error) {
+	defer shard.Close()
+	link := shard.Link()
+
+	rcpt, err := c.BlobAdd(ctx, blobadd.Caveat{
+		Blob: blobadd.Blob{
+			Digest: shard.Digest,
+			Size:   shard.Size,
+		},
+	})
 	if err != nil {
-		log.Fatalf("hashing CAR: %s", err)
+		return nil, fmt.Errorf("space/blob/add %s: %w", link, err)
 	}
 
-	link := cidlink.Link{Cid: cid.NewCidV1(0x0202, mh)}
+	_, addFailure := result.Unwrap(rcpt.Out())
+	if addFailure != nil {
+		return nil, failureError(blobadd.Ability, addFailure)
+	}
 
-	rcpt, err := client.StoreAdd(
-		issuer,
-		space,
-		storeadd.Caveat{
-			Link: link,
-			Size: uint64(buf.Len()),
-		},
-		client.WithConnection(util.MustGetConnection()),
-		client.WithProofs(proofs),
-	)
+	effects, err := client.ReadBlobAddEffects(rcpt)
 	if err != nil {
-		log.Fatalf("store/add %s: %s", link, err)
+		return nil, fmt.Errorf("reading space/blob/add effects: %w", err)
 	}
 
-	storeSuccess, storeFailure := result.Unwrap(rcpt.Out())
-	if storeFailure != nil {
-		log.Fatalf("%+v\n", storeFailure)
+	if effects.AllocateReceipt == nil {
+		return nil, fmt.Errorf("missing blob/allocate receipt")
 	}
 
-	if storeSuccess.Status == "upload" {
-		hr, err := http.NewRequest("PUT", *storeSuccess.Url, bytes.NewReader(buf.Bytes()))
-		if err != nil {
-			log.Fatalf("creating HTTP request: %s", err)
-		}
+	allocSuccess, allocFailure := result.Unwrap(effects.AllocateReceipt.Out())
+	if allocFailure != nil {
+		return nil, failureError(bloballocate.Ability, allocFailure)
+	}
 
-		hdr := map[string][]string{}
-		for k, v := range storeSuccess.Headers.Values {
-			if k == "content-length" {
-				continue
-			}
-			hdr[k] = []string{v}
+	if allocSuccess.Address != nil {
+		err = client.PutBlob(ctx, *allocSuccess.Address, shard.Reader(), shard.Size)
+		if err != nil {
+			return nil, fmt.Errorf("putting blob: %w", err)
 		}
+	}
 
-		hr.Header = hdr
-		hr.ContentLength = int64(buf.Len())
-		httpClient := http.Client{}
-		res, err := httpClient.Do(hr)
+	if effects.PutReceipt == nil {
+		rcpt, err := c.ConcludeHTTPPut(ctx, effects.Put)
 		if err != nil {
-			log.Fatalf("doing HTTP request: %s", err)
+			return nil, fmt.Errorf("ucan/conclude %s: %w", link, err)
 		}
-		if res.StatusCode != 200 {
-			log.Fatalf("non-200 status code while uploading file: %d", res.StatusCode)
+
+		_, concludeFailure := result.Unwrap(rcpt.Out())
+		if concludeFailure != nil {
+			return nil, failureError(ucanconclude.Ability, concludeFailure)
 		}
-		err = res.Body.Close()
+	}
+
+	if effects.AcceptReceipt == nil {
+		effects.AcceptReceipt, err = c.PollBlobAcceptReceipt(ctx, effects.Accept.Link())
 		if err != nil {
-			log.Fatalf("closing request body: %s", err)
+			return nil, fmt.Errorf("polling blob/accept receipt: %w", err)
 		}
 	}
 
-	return link
+	_, acceptFailure := result.Unwrap(effects.AcceptReceipt.Out())
+	if acceptFailure != nil {
+		return nil, failureError(blobaccept.Ability, acceptFailure)
+	}
+
+	return link, nil
 }
 
 func ls(cCtx *cli.Context) error {
-	signer := util.MustGetSigner()
-	conn := util.MustGetConnection()
-	space := util.MustParseDID(cCtx.String("space"))
-	proof := util.MustGetProof(cCtx.String("proof"))
-
-	rcpt, err := client.UploadList(
-		signer,
-		space,
-		uploadlist.Caveat{},
-		client.WithConnection(conn),
-		client.WithProofs([]delegation.Delegation{proof}),
-	)
+	c := mustGetClient(cCtx)
+
+	rcpt, err := c.UploadList(cCtx.Context, uploadlist.Caveat{})
 	if err != nil {
 		return err
 	}
 
 	lsSuccess, lsFailure := result.Unwrap(rcpt.Out())
 	if lsFailure != nil {
-		log.Fatalf("%+v\n", lsFailure)
+		fatalFailure(uploadlist.Ability, lsFailure)
 	}
 
 	for _, r := range lsSuccess.Results {
@@ -280,3 +449,27 @@ func ls(cCtx *cli.Context) error {
 
 	return nil
 }
+
+// fatalFailure exits with a failure reported by the service for an
+// invocation, along with a hint for failures the user can resolve.
+func fatalFailure(ability string, err error) {
+	log.Fatal(failureError(ability, err))
+}
+
+// failureError returns an error for a failure reported by the service for an
+// invocation, along with a hint for failures the user can resolve.
+func failureError(ability string, err error) error {
+	switch {
+	case errors.Is(err, failure.ErrInsufficientStorage):
+		return fmt.Errorf("%s: %w\nhint: the space needs a storage provider with enough capacity", ability, err)
+	case errors.Is(err, failure.ErrSpaceNotProvisioned):
+		return fmt.Errorf("%s: %w\nhint: the space needs to be provisioned", ability, err)
+	case errors.Is(err, failure.ErrUnauthorized):
+		return fmt.Errorf("%s: %w\nhint: check the proof delegates the capability to %s", ability, err, util.MustGetSigner().DID())
+	case errors.Is(err, failure.ErrRateLimited):
+		return fmt.Errorf("%s: %w\nhint: try again later", ability, err)
+	case errors.Is(err, failure.ErrAccountPlanMissing):
+		return fmt.Errorf("%s: %w\nhint: the account needs a billing plan, select one at https://console.web3.storage", ability, err)
+	}
+	return fmt.Errorf("%s: %w", ability, err)
+}
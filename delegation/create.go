@@ -0,0 +1,51 @@
+package delegation
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Create issues a delegation of the abilities on the resource from the issuer
+// to the audience. The issuer is typically a space (the resource itself) or an
+// agent that has been delegated the abilities, in which case the delegations
+// that prove it must be passed with `delegation.WithProof`.
+//
+// The delegation does not expire unless `delegation.WithExpiration` is passed.
+func Create(issuer principal.Signer, audience ucan.Principal, resource string, abilities []string, options ...delegation.Option) (delegation.Delegation, error) {
+	if len(abilities) == 0 {
+		return nil, fmt.Errorf("no abilities to delegate")
+	}
+
+	caps := make([]ucan.Capability[ucan.NoCaveats], 0, len(abilities))
+	for _, can := range abilities {
+		caps = append(caps, ucan.NewCapability(can, resource, ucan.NoCaveats{}))
+	}
+
+	opts := append([]delegation.Option{delegation.WithNoExpiration()}, options...)
+	dlg, err := delegation.Delegate(issuer, audience, caps, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating delegation: %s", err)
+	}
+	return dlg, nil
+}
+
+// Archive encodes the delegation, along with its proofs, as a CAR file that can
+// be read with `ExtractProof`.
+func Archive(dlg delegation.Delegation) ([]byte, error) {
+	b, err := io.ReadAll(delegation.Archive(dlg))
+	if err != nil {
+		return nil, fmt.Errorf("archiving delegation: %s", err)
+	}
+	return b, nil
+}
+
+// Format encodes the delegation as a base64 multibase string, the CAR archive
+// wrapped in an identity CID. This is the format produced by the w3up JS CLI
+// `w3 delegation create --base64`.
+func Format(dlg delegation.Delegation) (string, error) {
+	return delegation.Format(dlg)
+}
@@ -0,0 +1,86 @@
+package delegation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	cdg "github.com/storacha/go-w3up/delegation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	space := fixtures.Alice.DID().String()
+
+	t.Run("no expiration", func(t *testing.T) {
+		dlg, err := cdg.Create(fixtures.Alice, fixtures.Bob, space, []string{"upload/add", "store/add"})
+		require.NoError(t, err)
+
+		require.Equal(t, fixtures.Alice.DID(), dlg.Issuer().DID())
+		require.Equal(t, fixtures.Bob.DID(), dlg.Audience().DID())
+		require.Nil(t, dlg.Expiration())
+		require.Len(t, dlg.Capabilities(), 2)
+		require.Equal(t, "upload/add", dlg.Capabilities()[0].Can())
+		require.Equal(t, "store/add", dlg.Capabilities()[1].Can())
+		require.Equal(t, space, dlg.Capabilities()[1].With())
+	})
+
+	t.Run("expiration and proofs", func(t *testing.T) {
+		prf := helpers.Must(cdg.Create(fixtures.Alice, fixtures.Bob, space, []string{"*"}))
+		exp := int(time.Now().Add(time.Hour).Unix())
+
+		dlg, err := cdg.Create(
+			fixtures.Bob,
+			fixtures.Mallory,
+			space,
+			[]string{"upload/list"},
+			delegation.WithExpiration(exp),
+			delegation.WithProof(delegation.FromDelegation(prf)),
+		)
+		require.NoError(t, err)
+		require.Equal(t, ucan.UTCUnixTimestamp(exp), *dlg.Expiration())
+		require.Equal(t, []ucan.Link{prf.Link()}, dlg.Proofs())
+	})
+
+	t.Run("no abilities", func(t *testing.T) {
+		_, err := cdg.Create(fixtures.Alice, fixtures.Bob, space, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestArchive(t *testing.T) {
+	prf := helpers.Must(cdg.Create(fixtures.Alice, fixtures.Bob, fixtures.Alice.DID().String(), []string{"*"}))
+	dlg := helpers.Must(cdg.Create(
+		fixtures.Bob,
+		fixtures.Mallory,
+		fixtures.Alice.DID().String(),
+		[]string{"upload/add"},
+		delegation.WithProof(delegation.FromDelegation(prf)),
+	))
+
+	b, err := cdg.Archive(dlg)
+	require.NoError(t, err)
+	extracted, err := cdg.ExtractProof(b)
+	require.NoError(t, err)
+	require.Equal(t, dlg.Link(), extracted.Link())
+
+	s, err := cdg.Format(dlg)
+	require.NoError(t, err)
+	require.Equal(t, byte('m'), s[0])
+	parsed, err := delegation.Parse(s)
+	require.NoError(t, err)
+	require.Equal(t, dlg.Link(), parsed.Link())
+
+	// proofs are included in the archive
+	var found bool
+	for blk, err := range parsed.Blocks() {
+		require.NoError(t, err)
+		if blk.Link().String() == prf.Link().String() {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
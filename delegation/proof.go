@@ -2,57 +2,270 @@ package delegation
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/ipfs/go-cid"
+	_ "github.com/ipld/go-ipld-prime/codec/dagcbor"
+	_ "github.com/ipld/go-ipld-prime/codec/dagjson"
+	_ "github.com/ipld/go-ipld-prime/codec/raw"
+	ipldmc "github.com/ipld/go-ipld-prime/multicodec"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-ucanto/core/car"
 	"github.com/storacha/go-ucanto/core/dag/blockstore"
 	"github.com/storacha/go-ucanto/core/delegation"
+	adm "github.com/storacha/go-ucanto/core/delegation/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
 	"github.com/storacha/go-ucanto/core/ipld/block"
+	"github.com/storacha/go-ucanto/core/ipld/codec/cbor"
+	"github.com/storacha/go-ucanto/core/ipld/hash/sha256"
+	udm "github.com/storacha/go-ucanto/ucan/datamodel/ucan"
 )
 
-// ExtractProof is a temporary helper to extract a proof from a proof archive
-// with fallback to extracting from the legacy encoding.
-//
-// It will first attempt to extract using `delegation.Extract` from
-// `go-ucanto/core/delegation` and falls back to decoding by reading a plain
-// CAR file, assuming the last block is the delegation root.
+// ExtractProof extracts a single delegation from a proof. See `ExtractProofs`
+// for the supported encodings. It returns an error if the proof contains more
+// than one delegation.
 func ExtractProof(b []byte) (delegation.Delegation, error) {
-	proof, err := delegation.Extract(b)
+	dlgs, err := ExtractProofs(b)
 	if err != nil {
-		// try decode legacy format
-		_, blocks, err := car.Decode(bytes.NewReader(b))
+		return nil, err
+	}
+	if len(dlgs) > 1 {
+		return nil, fmt.Errorf("extracting proof: found %d delegations, expected 1", len(dlgs))
+	}
+	return dlgs[0], nil
+}
+
+// ExtractProofs extracts the delegations from a proof in any of the encodings
+// emitted by w3up tooling:
+//
+//   - a delegation archive, as created by `Archive` or `w3 delegation create`,
+//   - a CAR file whose roots are delegation archives or delegations, which may
+//     contain several delegations,
+//   - a legacy CAR file with no roots, where the delegation is the block not
+//     linked to by any other block,
+//   - an identity CID string wrapping any of the above, in any multibase
+//     encoding, as created by `Format` or `w3 delegation create --base64`,
+//   - a multibase base64 string of any of the above.
+//
+// Leading and trailing whitespace is ignored for string encodings.
+func ExtractProofs(b []byte) ([]delegation.Delegation, error) {
+	if s := strings.TrimSpace(string(b)); isMultibaseString(s) {
+		dlgs, err := extractString(s)
 		if err != nil {
 			return nil, fmt.Errorf("extracting proof: %s", err)
 		}
+		return dlgs, nil
+	}
+
+	dlgs, err := extractCAR(b)
+	if err != nil {
+		return nil, fmt.Errorf("extracting proof: %s", err)
+	}
+	return dlgs, nil
+}
+
+// isMultibaseString reports whether the input looks like a multibase string
+// rather than binary CAR data.
+func isMultibaseString(s string) bool {
+	if s == "" || !utf8.ValidString(s) || strings.ContainsFunc(s, func(r rune) bool { return r <= ' ' || r == utf8.RuneError }) {
+		return false
+	}
+	_, _, err := multibase.Decode(s)
+	return err == nil
+}
+
+// extractString extracts delegations from a multibase string, which is either
+// an identity CID of a CAR file or the CAR file itself.
+func extractString(s string) ([]delegation.Delegation, error) {
+	if c, err := cid.Decode(s); err == nil {
+		return extractCID(c)
+	}
+
+	_, data, err := multibase.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding multibase: %s", err)
+	}
+	if _, c, err := cid.CidFromBytes(data); err == nil && c.Prefix().Codec == uint64(multicodec.Car) {
+		return extractCID(c)
+	}
+	return extractCAR(data)
+}
+
+// extractCID extracts delegations from the CAR file inlined in an identity CID.
+func extractCID(c cid.Cid) ([]delegation.Delegation, error) {
+	if codec := multicodec.Code(c.Prefix().Codec); codec != multicodec.Car {
+		return nil, fmt.Errorf("CID %s is not a CAR file: codec is %s", c, codec)
+	}
+	mh, err := multihash.Decode(c.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("decoding multihash: %s", err)
+	}
+	if code := multicodec.Code(mh.Code); code != multicodec.Identity {
+		return nil, fmt.Errorf("CID %s does not inline its data: multihash is %s", c, code)
+	}
+	return extractCAR(mh.Digest)
+}
+
+// extractCAR extracts delegations from a CAR file. Each root of the CAR is
+// either a delegation archive or a delegation. If the CAR has no roots, the
+// root is the single block not referenced by any other block, see `findRoot`.
+func extractCAR(b []byte) ([]delegation.Delegation, error) {
+	roots, blocks, err := car.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("decoding CAR: %s", err)
+	}
+
+	bs, err := blockstore.NewBlockStore()
+	if err != nil {
+		return nil, fmt.Errorf("creating blockstore: %s", err)
+	}
+
+	var blks []ipld.Block
+	for blk, err := range blocks {
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("reading block: %s", err)
+		}
+		if err := bs.Put(blk); err != nil {
+			return nil, fmt.Errorf("putting block: %s", err)
+		}
+		blks = append(blks, blk)
+	}
 
-		var rt block.Block
-		bs, err := blockstore.NewBlockStore()
+	// legacy format: the CAR has no roots in its header
+	if len(roots) == 0 {
+		root, err := findRoot(blks)
 		if err != nil {
-			return nil, fmt.Errorf("creating blockstore: %s", err)
+			return nil, err
 		}
+		dlg, err := extractRoot(root, bs)
+		if err != nil {
+			return nil, err
+		}
+		return []delegation.Delegation{dlg}, nil
+	}
 
-		for bl, err := range blocks {
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
+	var dlgs []delegation.Delegation
+	for _, root := range roots {
+		dlg, err := extractRoot(root, bs)
+		if err != nil {
+			return nil, err
+		}
+		dlgs = append(dlgs, dlg)
+	}
+	return dlgs, nil
+}
 
-				return nil, fmt.Errorf("reading block: %s", err)
-			}
+// findRoot finds the root of a CAR file without roots in its header, which is
+// the block not linked to by any other block, regardless of the order the
+// blocks are written in. It fails if there is no such block or more than one,
+// since the delegation cannot be identified. Blocks that cannot be decoded are
+// assumed to have no links.
+func findRoot(blks []ipld.Block) (ipld.Link, error) {
+	if len(blks) == 0 {
+		return nil, errors.New("CAR contains no blocks")
+	}
 
-			if err := bs.Put(bl); err != nil {
-				return nil, fmt.Errorf("putting block: %s", err)
-			}
+	referenced := map[string]struct{}{}
+	for _, blk := range blks {
+		for _, l := range blockLinks(blk) {
+			referenced[l.String()] = struct{}{}
+		}
+	}
 
-			rt = bl
+	var candidates []ipld.Link
+	seen := map[string]struct{}{}
+	for _, blk := range blks {
+		k := blk.Link().String()
+		if _, ok := referenced[k]; ok {
+			continue
+		}
+		if _, ok := seen[k]; ok {
+			continue
 		}
+		seen[k] = struct{}{}
+		candidates = append(candidates, blk.Link())
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, errors.New("CAR has no roots and every block is linked to by another block")
+	case 1:
+		return candidates[0], nil
+	default:
+		var ls []string
+		for _, c := range candidates {
+			ls = append(ls, c.String())
+		}
+		return nil, fmt.Errorf("CAR has no roots and %d blocks are not linked to by any other block, cannot tell which is the delegation: %s", len(candidates), strings.Join(ls, ", "))
+	}
+}
+
+// blockLinks returns the links in a block, or nil if the block cannot be
+// decoded.
+func blockLinks(blk ipld.Block) []ipld.Link {
+	c, err := cid.Parse(blk.Link().String())
+	if err != nil {
+		return nil
+	}
+	decoder, err := ipldmc.LookupDecoder(c.Prefix().Codec)
+	if err != nil {
+		return nil
+	}
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := decoder(nb, bytes.NewReader(blk.Bytes())); err != nil {
+		return nil
+	}
+	links, err := traversal.SelectLinks(nb.Build())
+	if err != nil {
+		return nil
+	}
+	return links
+}
 
-		proof, err = delegation.NewDelegation(rt, bs)
+// extractRoot reads the delegation identified by a CAR root, which is either
+// a delegation archive variant block or the delegation itself.
+func extractRoot(root ipld.Link, bs blockstore.BlockReader) (delegation.Delegation, error) {
+	rt, ok, err := bs.Get(root)
+	if err != nil {
+		return nil, fmt.Errorf("getting root block %s: %s", root, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("missing root block: %s", root)
+	}
+
+	model := adm.ArchiveModel{}
+	if err := block.Decode(rt, &model, adm.Type(), cbor.Codec, sha256.Hasher); err == nil {
+		rt, ok, err = bs.Get(model.Ucan0_9_1)
 		if err != nil {
-			return nil, fmt.Errorf("creating delegation: %s", err)
+			return nil, fmt.Errorf("getting delegation block %s: %s", model.Ucan0_9_1, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("missing delegation block: %s", model.Ucan0_9_1)
 		}
 	}
+	return newDelegation(rt, bs)
+}
 
-	return proof, nil
+// newDelegation creates a delegation from its root block, checking that the
+// block is a UCAN.
+func newDelegation(rt ipld.Block, bs blockstore.BlockReader) (delegation.Delegation, error) {
+	if err := block.Decode(rt, &udm.UCANModel{}, udm.Type(), cbor.Codec, sha256.Hasher); err != nil {
+		return nil, fmt.Errorf("decoding delegation %s: %s", rt.Link(), err)
+	}
+	dlg, err := delegation.NewDelegation(rt, bs)
+	if err != nil {
+		return nil, fmt.Errorf("creating delegation %s: %s", rt.Link(), err)
+	}
+	return dlg, nil
 }
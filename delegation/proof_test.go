@@ -0,0 +1,147 @@
+package delegation_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	cdg "github.com/storacha/go-w3up/delegation"
+	"github.com/stretchr/testify/require"
+)
+
+func links(dlgs []delegation.Delegation) []string {
+	var ls []string
+	for _, d := range dlgs {
+		ls = append(ls, d.Link().String())
+	}
+	return ls
+}
+
+func blocks(t *testing.T, dlgs ...delegation.Delegation) []ipld.Block {
+	t.Helper()
+	var blks []ipld.Block
+	for _, d := range dlgs {
+		for blk, err := range d.Blocks() {
+			require.NoError(t, err)
+			blks = append(blks, blk)
+		}
+	}
+	return blks
+}
+
+// encodeBlocks encodes the blocks in order as a CAR file with the passed
+// roots.
+func encodeBlocks(roots []ipld.Link, blks []ipld.Block) []byte {
+	return helpers.Must(io.ReadAll(car.Encode(roots, func(yield func(ipld.Block, error) bool) {
+		for _, blk := range blks {
+			if !yield(blk, nil) {
+				return
+			}
+		}
+	})))
+}
+
+// encodeCAR encodes the blocks of the delegations as a CAR file with the
+// passed roots.
+func encodeCAR(t *testing.T, roots []ipld.Link, dlgs ...delegation.Delegation) []byte {
+	t.Helper()
+	return encodeBlocks(roots, blocks(t, dlgs...))
+}
+
+func identityCID(t *testing.T, data []byte) cid.Cid {
+	t.Helper()
+	digest := helpers.Must(multihash.Sum(data, multihash.IDENTITY, -1))
+	return cid.NewCidV1(uint64(multicodec.Car), digest)
+}
+
+func TestExtractProofs(t *testing.T) {
+	resource := fixtures.Alice.DID().String()
+	root := delegate(t, fixtures.Alice, fixtures.Bob, "*", resource)
+	dlg := delegate(t, fixtures.Bob, fixtures.Mallory, "upload/add", resource, withProof(root))
+	other := delegate(t, fixtures.Alice, fixtures.Mallory, "store/add", resource)
+
+	archive := helpers.Must(cdg.Archive(dlg))
+	_, archiveBlocks, err := car.Decode(bytes.NewReader(archive))
+	require.NoError(t, err)
+	var unrooted []ipld.Block
+	for blk, err := range archiveBlocks {
+		require.NoError(t, err)
+		unrooted = append(unrooted, blk)
+	}
+
+	reversed := blocks(t, dlg)
+	slices.Reverse(reversed)
+
+	single := map[string][]byte{
+		"archive":                archive,
+		"identity CID base64":    []byte(helpers.Must(cdg.Format(dlg))),
+		"identity CID base32":    []byte(identityCID(t, archive).String()),
+		"identity CID bytes b64": []byte(helpers.Must(multibase.Encode(multibase.Base64, identityCID(t, archive).Bytes()))),
+		"CAR base64":             []byte(helpers.Must(multibase.Encode(multibase.Base64, archive))),
+		"trailing newline":       []byte(helpers.Must(cdg.Format(dlg)) + "\n"),
+		"delegation root":        encodeCAR(t, []ipld.Link{dlg.Link()}, dlg),
+		"legacy":                 encodeCAR(t, nil, dlg),
+		"legacy root first":      encodeBlocks(nil, reversed),
+		"legacy archive":         encodeBlocks(nil, unrooted),
+	}
+	for name, b := range single {
+		t.Run(name, func(t *testing.T) {
+			dlgs, err := cdg.ExtractProofs(b)
+			require.NoError(t, err)
+			require.Equal(t, links([]delegation.Delegation{dlg}), links(dlgs))
+			// proofs are available
+			require.NoError(t, cdg.Validate(dlgs[0], fixtures.Mallory.DID(), "upload/add", resource, 0))
+
+			extracted, err := cdg.ExtractProof(b)
+			require.NoError(t, err)
+			require.Equal(t, dlg.Link(), extracted.Link())
+		})
+	}
+
+	t.Run("multiple delegations", func(t *testing.T) {
+		b := encodeCAR(t, []ipld.Link{dlg.Link(), other.Link()}, dlg, other)
+
+		dlgs, err := cdg.ExtractProofs(b)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg, other}), links(dlgs))
+
+		dlgs, err = cdg.ExtractProofs([]byte(identityCID(t, b).String()))
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg, other}), links(dlgs))
+
+		_, err = cdg.ExtractProof(b)
+		require.ErrorContains(t, err, "found 2 delegations")
+	})
+
+	t.Run("ambiguous legacy root", func(t *testing.T) {
+		_, err := cdg.ExtractProofs(encodeCAR(t, nil, dlg, other))
+		require.ErrorContains(t, err, "2 blocks are not linked to by any other block")
+		require.ErrorContains(t, err, dlg.Link().String())
+		require.ErrorContains(t, err, other.Link().String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		inputs := map[string][]byte{
+			"empty":        nil,
+			"text":         []byte("not a proof"),
+			"non CAR CID":  []byte(dlg.Link().String()),
+			"non identity": []byte(cid.NewCidV1(uint64(multicodec.Car), helpers.Must(multihash.Sum(archive, multihash.SHA2_256, -1))).String()),
+			"truncated":    archive[:len(archive)/2],
+			"missing root": encodeCAR(t, []ipld.Link{other.Link()}, dlg),
+		}
+		for name, b := range inputs {
+			_, err := cdg.ExtractProofs(b)
+			require.Error(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package delegation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	edverifier "github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	rsaverifier "github.com/storacha/go-ucanto/principal/rsa/verifier"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/proof"
+)
+
+// ErrUnattested is returned by `Validate` when a delegation in the chain is
+// issued by a principal whose signature cannot be verified locally (e.g. a
+// `did:mailto:` account) and there is no valid `ucan/attest` attestation for it
+// from the authority configured with `WithAuthority`.
+var ErrUnattested = errors.New("delegation is not attested")
+
+// ValidateOption is an option configuring `Validate`.
+type ValidateOption func(cfg *validateConfig) error
+
+type validateConfig struct {
+	authority    principal.Verifier
+	attestations []delegation.Delegation
+}
+
+// WithAuthority configures the service that attests to delegations issued by
+// principals that are not `did:key`s. Attestations must be issued by its DID
+// and are verified with its key.
+func WithAuthority(authority principal.Verifier) ValidateOption {
+	return func(cfg *validateConfig) error {
+		cfg.authority = authority
+		return nil
+	}
+}
+
+// WithAttestations configures `ucan/attest` attestations to look for in
+// addition to those included in the delegation, e.g. the session proofs
+// claimed when logging in.
+func WithAttestations(attestations ...delegation.Delegation) ValidateOption {
+	return func(cfg *validateConfig) error {
+		cfg.attestations = append(cfg.attestations, attestations...)
+		return nil
+	}
+}
+
+// Validate checks locally that the delegation proves the issuer may invoke the
+// ability on the resource at the time `now`, so that invalid proofs are found
+// before they are sent to the service. Across the whole delegation chain it
+// checks that:
+//
+//   - each delegation is issued to the issuer of the delegation it proves (or
+//     to `issuer` for the passed delegation),
+//   - each delegation is valid at `now`, i.e. has not expired and is not used
+//     before its not before time,
+//   - each delegation has a valid signature from its issuer,
+//   - each delegation grants a capability that covers the ability (including
+//     `*` and `<namespace>/*` wildcards) on the resource (or `ucan:*`),
+//   - the chain ends in a delegation issued by the resource itself.
+//
+// Proofs must be included in the blocks of the delegation. Signatures can only
+// be verified for `did:key` issuers and for the authority configured with
+// `WithAuthority`. A delegation issued by another principal
+// (e.g. a `did:mailto:` account) must instead be attested by a `ucan/attest`
+// delegation from the authority configured with `WithAuthority`, either
+// included in the proofs or passed with `WithAttestations`, otherwise an error
+// wrapping `ErrUnattested` is returned. Caveats are not checked.
+func Validate(dlg delegation.Delegation, issuer did.DID, ability string, resource string, now ucan.UTCUnixTimestamp, options ...ValidateOption) error {
+	cfg := validateConfig{}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return err
+		}
+	}
+	bs, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(dlg.Blocks()))
+	if err != nil {
+		return fmt.Errorf("creating block reader: %s", err)
+	}
+	v := validator{
+		bs:           bs,
+		authority:    cfg.authority,
+		attestations: append(cfg.attestations, includedAttestations(bs, dlg, map[string]struct{}{}, 0)...),
+		ability:      ability,
+		resource:     resource,
+		now:          now,
+	}
+	return v.validate(dlg, issuer, 0)
+}
+
+type validator struct {
+	bs           blockstore.BlockReader
+	authority    principal.Verifier
+	attestations []delegation.Delegation
+	ability      string
+	resource     string
+	now          ucan.UTCUnixTimestamp
+}
+
+func (v validator) validate(dlg delegation.Delegation, audience did.DID, depth int) error {
+	if depth > proof.MaxDepth {
+		return fmt.Errorf("delegation %s: chain exceeds maximum depth of %d", dlg.Link(), proof.MaxDepth)
+	}
+	if err := v.check(dlg, audience); err != nil {
+		return fmt.Errorf("delegation %s: %w", dlg.Link(), err)
+	}
+
+	// the resource is the root authority
+	if dlg.Issuer().DID().String() == v.resource {
+		return nil
+	}
+	if len(dlg.Proofs()) == 0 {
+		return fmt.Errorf("delegation %s: issuer %s is not %s and there are no proofs", dlg.Link(), dlg.Issuer().DID(), v.resource)
+	}
+
+	var errs []error
+	for _, link := range dlg.Proofs() {
+		if _, ok, _ := v.bs.Get(link); !ok {
+			errs = append(errs, fmt.Errorf("delegation %s: proof %s is not included", dlg.Link(), link))
+			continue
+		}
+		prf, err := delegation.NewDelegationView(link, v.bs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delegation %s: reading proof %s: %s", dlg.Link(), link, err))
+			continue
+		}
+		err = v.validate(prf, dlg.Issuer().DID(), depth+1)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// includedAttestations returns the `ucan/attest` delegations among the proofs
+// of the delegation, recursively.
+func includedAttestations(bs blockstore.BlockReader, dlg delegation.Delegation, seen map[string]struct{}, depth int) []delegation.Delegation {
+	if depth > proof.MaxDepth {
+		return nil
+	}
+	var attestations []delegation.Delegation
+	for _, link := range dlg.Proofs() {
+		if _, ok := seen[link.String()]; ok {
+			continue
+		}
+		seen[link.String()] = struct{}{}
+		if _, ok, _ := bs.Get(link); !ok {
+			continue
+		}
+		prf, err := delegation.NewDelegationView(link, bs)
+		if err != nil {
+			continue
+		}
+		if len(proof.Attests(prf)) > 0 {
+			attestations = append(attestations, prf)
+		}
+		attestations = append(attestations, includedAttestations(bs, prf, seen, depth+1)...)
+	}
+	return attestations
+}
+
+// check validates a single delegation in the chain.
+func (v validator) check(dlg delegation.Delegation, audience did.DID) error {
+	if dlg.Audience().DID() != audience {
+		return fmt.Errorf("audience %s is not %s", dlg.Audience().DID(), audience)
+	}
+	if exp := dlg.Expiration(); exp != nil && *exp <= v.now {
+		return fmt.Errorf("expired at %d", *exp)
+	}
+	if nbf := dlg.NotBefore(); nbf > v.now {
+		return fmt.Errorf("not valid before %d", nbf)
+	}
+	if err := v.verifyIssuer(dlg); err != nil {
+		return err
+	}
+
+	for _, cap := range dlg.Capabilities() {
+		if !proof.AbilityMatches(cap.Can(), v.ability) {
+			continue
+		}
+		if cap.With() == v.resource || cap.With() == "ucan:*" {
+			return nil
+		}
+	}
+	return fmt.Errorf("no capability covers %s on %s", v.ability, v.resource)
+}
+
+// verifyIssuer verifies the signature of a delegation issued by a `did:key` or
+// by the authority, or that a delegation issued by another principal is
+// attested by the authority.
+func (v validator) verifyIssuer(dlg delegation.Delegation) error {
+	issuer := dlg.Issuer().DID().String()
+	if strings.HasPrefix(issuer, "did:key:") {
+		return verifySignature(dlg)
+	}
+	if v.authority == nil {
+		return fmt.Errorf("%w: issuer %s is not a did:key and no attesting authority is configured", ErrUnattested, issuer)
+	}
+
+	authority := v.authority.DID()
+	if dlg.Issuer().DID() == authority {
+		ok, err := ucan.VerifySignature(dlg.Data(), v.authority)
+		if err != nil {
+			return fmt.Errorf("verifying signature: %s", err)
+		}
+		if !ok {
+			return fmt.Errorf("invalid signature from %s", issuer)
+		}
+		return nil
+	}
+	for _, att := range v.attestations {
+		if att.Issuer().DID() != authority || isInactive(att, v.now) || !attests(att, authority, dlg) {
+			continue
+		}
+		ok, err := ucan.VerifySignature(att.Data(), v.authority)
+		if err == nil && ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no valid %s from %s for issuer %s", ErrUnattested, proof.AttestAbility, authority, issuer)
+}
+
+func isInactive(dlg delegation.Delegation, now ucan.UTCUnixTimestamp) bool {
+	if exp := dlg.Expiration(); exp != nil && *exp <= now {
+		return true
+	}
+	return dlg.NotBefore() > now
+}
+
+// attests reports whether the attestation has a `ucan/attest` capability on
+// the authority for the delegation.
+func attests(att delegation.Delegation, authority did.DID, dlg delegation.Delegation) bool {
+	for _, cap := range att.Capabilities() {
+		if cap.Can() == proof.AttestAbility && cap.With() == authority.String() {
+			for _, link := range proof.Attests(att) {
+				if link.String() == dlg.Link().String() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func verifySignature(dlg delegation.Delegation) error {
+	issuer := dlg.Issuer().DID().String()
+	verifier, err := parseVerifier(issuer)
+	if err != nil {
+		return fmt.Errorf("parsing issuer %s: %s", issuer, err)
+	}
+	ok, err := ucan.VerifySignature(dlg.Data(), verifier)
+	if err != nil {
+		return fmt.Errorf("verifying signature: %s", err)
+	}
+	if !ok {
+		return fmt.Errorf("invalid signature from %s", issuer)
+	}
+	return nil
+}
+
+func parseVerifier(key string) (principal.Verifier, error) {
+	if v, err := edverifier.Parse(key); err == nil {
+		return v, nil
+	}
+	return rsaverifier.Parse(key)
+}
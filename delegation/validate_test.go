@@ -0,0 +1,165 @@
+package delegation_test
+
+import (
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	psigner "github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	cdg "github.com/storacha/go-w3up/delegation"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func delegate(t *testing.T, issuer principal.Signer, audience ucan.Principal, can string, with string, options ...delegation.Option) delegation.Delegation {
+	t.Helper()
+	return helpers.Must(cdg.Create(issuer, audience, with, []string{can}, options...))
+}
+
+func withProof(prf delegation.Delegation) delegation.Option {
+	return delegation.WithProof(delegation.FromDelegation(prf))
+}
+
+type attestCaveat struct {
+	proof ipld.Link
+}
+
+func (c attestCaveat) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "proof", qp.Link(c.proof))
+	})
+}
+
+// attest issues a `ucan/attest` attestation for the delegation, as the service
+// does for account delegations.
+func attest(t *testing.T, service principal.Signer, audience ucan.Principal, dlg delegation.Delegation, options ...delegation.Option) delegation.Delegation {
+	t.Helper()
+	return helpers.Must(delegation.Delegate(
+		service,
+		audience,
+		[]ucan.Capability[attestCaveat]{ucan.NewCapability(proof.AttestAbility, service.DID().String(), attestCaveat{dlg.Link()})},
+		append([]delegation.Option{delegation.WithNoExpiration()}, options...)...,
+	))
+}
+
+func TestValidate(t *testing.T) {
+	space := helpers.Must(signer.Generate())
+	resource := space.DID().String()
+	now := ucan.Now()
+
+	root := delegate(t, space, fixtures.Alice, "upload/*", resource)
+
+	t.Run("valid chain", func(t *testing.T) {
+		dlg := delegate(t, fixtures.Alice, fixtures.Bob, "upload/add", resource, withProof(root))
+		require.NoError(t, cdg.Validate(dlg, fixtures.Bob.DID(), "upload/add", resource, now))
+		require.NoError(t, cdg.Validate(root, fixtures.Alice.DID(), "upload/list", resource, now))
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		dlg := delegate(t, fixtures.Alice, fixtures.Bob, "upload/add", resource, withProof(root))
+		err := cdg.Validate(dlg, fixtures.Mallory.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "audience")
+
+		// the proof is not delegated to the issuer of the delegation
+		dlg = delegate(t, fixtures.Mallory, fixtures.Bob, "upload/add", resource, withProof(root))
+		err = cdg.Validate(dlg, fixtures.Bob.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "audience")
+	})
+
+	t.Run("expired proof", func(t *testing.T) {
+		expired := delegate(t, space, fixtures.Alice, "upload/*", resource, delegation.WithExpiration(int(now)-60))
+		dlg := delegate(t, fixtures.Alice, fixtures.Bob, "upload/add", resource, withProof(expired))
+		err := cdg.Validate(dlg, fixtures.Bob.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "expired")
+		require.ErrorContains(t, err, expired.Link().String())
+
+		future := delegate(t, fixtures.Alice, fixtures.Bob, "upload/add", resource, withProof(root), delegation.WithNotBefore(int(now)+60))
+		err = cdg.Validate(future, fixtures.Bob.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "not valid before")
+	})
+
+	t.Run("escalation", func(t *testing.T) {
+		dlg := delegate(t, fixtures.Alice, fixtures.Bob, "store/add", resource, withProof(root))
+		err := cdg.Validate(dlg, fixtures.Bob.DID(), "store/add", resource, now)
+		require.ErrorContains(t, err, "no capability covers store/add")
+
+		other := helpers.Must(signer.Generate()).DID().String()
+		err = cdg.Validate(root, fixtures.Alice.DID(), "upload/add", other, now)
+		require.Error(t, err)
+	})
+
+	t.Run("missing proof", func(t *testing.T) {
+		dlg := delegate(t, fixtures.Alice, fixtures.Bob, "upload/add", resource, delegation.WithProof(delegation.FromLink(root.Link())))
+		err := cdg.Validate(dlg, fixtures.Bob.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "not included")
+
+		dlg = delegate(t, fixtures.Alice, fixtures.Bob, "upload/add", resource)
+		err = cdg.Validate(dlg, fixtures.Bob.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "no proofs")
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		// signed by mallory, claiming to be issued by alice
+		forger := helpers.Must(psigner.Wrap(fixtures.Mallory, fixtures.Alice.DID()))
+		dlg := delegate(t, forger, fixtures.Bob, "upload/add", resource, withProof(root))
+		err := cdg.Validate(dlg, fixtures.Bob.DID(), "upload/add", resource, now)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("account", func(t *testing.T) {
+		service := helpers.Must(psigner.Wrap(fixtures.Service, helpers.Must(did.Parse("did:web:test.web3.storage"))))
+		authority := cdg.WithAuthority(service.Verifier())
+
+		account := helpers.Must(psigner.Wrap(helpers.Must(signer.Generate()), helpers.Must(did.Parse("did:mailto:example.com:alice"))))
+		carol := helpers.Must(signer.Generate())
+		spaceToAccount := delegate(t, space, account, "*", resource)
+		session := delegate(t, account, fixtures.Bob, "*", "ucan:*", withProof(spaceToAccount))
+		attestation := attest(t, service, fixtures.Bob, session)
+
+		// as the JS client does, the attestation is passed alongside the
+		// session delegation
+		dlg := delegate(t, fixtures.Bob, carol, "upload/add", resource, delegation.WithProof(delegation.FromDelegation(session), delegation.FromDelegation(attestation)))
+		require.NoError(t, cdg.Validate(dlg, carol.DID(), "upload/add", resource, now, authority))
+
+		require.NoError(t, cdg.Validate(session, fixtures.Bob.DID(), "upload/add", resource, now, authority, cdg.WithAttestations(attestation)))
+
+		t.Run("no authority", func(t *testing.T) {
+			err := cdg.Validate(dlg, carol.DID(), "upload/add", resource, now)
+			require.ErrorIs(t, err, cdg.ErrUnattested)
+		})
+
+		t.Run("forged without attestation", func(t *testing.T) {
+			// signed by mallory, claiming to be issued by the account
+			forger := helpers.Must(psigner.Wrap(fixtures.Mallory, account.DID()))
+			forged := delegate(t, forger, fixtures.Mallory, "*", "ucan:*", withProof(spaceToAccount))
+			err := cdg.Validate(forged, fixtures.Mallory.DID(), "upload/add", resource, now, authority)
+			require.ErrorIs(t, err, cdg.ErrUnattested)
+
+			// an attestation for another delegation does not help
+			err = cdg.Validate(forged, fixtures.Mallory.DID(), "upload/add", resource, now, authority, cdg.WithAttestations(attestation))
+			require.ErrorIs(t, err, cdg.ErrUnattested)
+		})
+
+		t.Run("forged attestation", func(t *testing.T) {
+			forger := helpers.Must(psigner.Wrap(fixtures.Mallory, service.DID()))
+			forged := attest(t, forger, fixtures.Bob, session)
+			err := cdg.Validate(session, fixtures.Bob.DID(), "upload/add", resource, now, authority, cdg.WithAttestations(forged))
+			require.ErrorIs(t, err, cdg.ErrUnattested)
+		})
+
+		t.Run("expired attestation", func(t *testing.T) {
+			expired := attest(t, service, fixtures.Bob, session, delegation.WithExpiration(int(now)-60))
+			err := cdg.Validate(session, fixtures.Bob.DID(), "upload/add", resource, now, authority, cdg.WithAttestations(expired))
+			require.ErrorIs(t, err, cdg.ErrUnattested)
+		})
+	})
+}
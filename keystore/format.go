@@ -0,0 +1,163 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+)
+
+// Format is an encoding of an ed25519 agent key.
+type Format string
+
+const (
+	// FormatAuto detects the format of a key when decoding.
+	FormatAuto Format = ""
+	// FormatMultibase is the multibase encoded key used by go-ucanto, ucanto and
+	// `W3UP_PRIVATE_KEY`, e.g. `MgCb+bRGl02JqlW...`. When decoding, lines
+	// starting with `#` (such as the DID comment printed by `ucan-key`) are
+	// ignored.
+	FormatMultibase Format = "multibase"
+	// FormatSeed is the raw 32 byte ed25519 seed. When decoding, the seed may
+	// also be hex encoded.
+	FormatSeed Format = "seed"
+	// FormatPEM is a PEM encoded PKCS#8 private key, as used by OpenSSL.
+	FormatPEM Format = "pem"
+	// FormatJSON is the JSON object printed by `w3 key create --json` in the JS
+	// CLI, with the `did` and the multibase encoded `key`.
+	FormatJSON Format = "json"
+)
+
+// Formats are the formats keys can be encoded in.
+var Formats = []Format{FormatMultibase, FormatSeed, FormatPEM, FormatJSON}
+
+const pemType = "PRIVATE KEY"
+
+type jsonKey struct {
+	DID string `json:"did"`
+	Key string `json:"key"`
+}
+
+// EncodeKey encodes an ed25519 signer in the passed format.
+func EncodeKey(s principal.Signer, format Format) ([]byte, error) {
+	if s.Code() != signer.Code {
+		return nil, fmt.Errorf("unsupported key type: %d", s.Code())
+	}
+
+	switch format {
+	case FormatMultibase:
+		str, err := signer.Format(s)
+		if err != nil {
+			return nil, fmt.Errorf("formatting key: %s", err)
+		}
+		return []byte(str), nil
+	case FormatSeed:
+		return ed25519.PrivateKey(s.Raw()).Seed(), nil
+	case FormatPEM:
+		der, err := x509.MarshalPKCS8PrivateKey(ed25519.PrivateKey(s.Raw()))
+		if err != nil {
+			return nil, fmt.Errorf("encoding PKCS#8 key: %s", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der}), nil
+	case FormatJSON:
+		str, err := signer.Format(s)
+		if err != nil {
+			return nil, fmt.Errorf("formatting key: %s", err)
+		}
+		return json.Marshal(jsonKey{DID: s.DID().String(), Key: str})
+	default:
+		return nil, fmt.Errorf("unsupported key format: %q", format)
+	}
+}
+
+// DecodeKey decodes an ed25519 signer encoded in the passed format. If the
+// format is `FormatAuto` it is detected from the data.
+func DecodeKey(b []byte, format Format) (principal.Signer, error) {
+	if format == FormatAuto {
+		format = detectFormat(b)
+	}
+
+	switch format {
+	case FormatMultibase:
+		var lines []string
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) != 1 {
+			return nil, fmt.Errorf("expected a single multibase key, found %d lines", len(lines))
+		}
+		s, err := signer.Parse(lines[0])
+		if err != nil {
+			return nil, fmt.Errorf("parsing multibase key: %s", err)
+		}
+		return s, nil
+	case FormatSeed:
+		seed := b
+		if str := strings.TrimSpace(string(b)); len(str) == 2*ed25519.SeedSize {
+			if decoded, err := hex.DecodeString(str); err == nil {
+				seed = decoded
+			}
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid seed length: %d wanted: %d", len(seed), ed25519.SeedSize)
+		}
+		return signer.FromRaw(ed25519.NewKeyFromSeed(seed))
+	case FormatPEM:
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM block found")
+		}
+		if block.Type != pemType {
+			return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing PKCS#8 key: %s", err)
+		}
+		pk, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type: %T", key)
+		}
+		return signer.FromRaw(pk)
+	case FormatJSON:
+		var k jsonKey
+		if err := json.Unmarshal(b, &k); err != nil {
+			return nil, fmt.Errorf("decoding JSON key: %s", err)
+		}
+		s, err := signer.Parse(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("parsing multibase key: %s", err)
+		}
+		if k.DID != "" && k.DID != s.DID().String() {
+			return nil, fmt.Errorf("key is for %s, not %s", s.DID(), k.DID)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported key format: %q", format)
+	}
+}
+
+func detectFormat(b []byte) Format {
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatJSON
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+		return FormatPEM
+	case len(b) == ed25519.SeedSize || len(trimmed) == 2*ed25519.SeedSize:
+		return FormatSeed
+	default:
+		return FormatMultibase
+	}
+}
@@ -0,0 +1,164 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KDFScrypt identifies keys derived from the passphrase with scrypt.
+	KDFScrypt = "scrypt"
+	// CipherXChaCha20Poly1305 identifies data encrypted with XChaCha20-Poly1305.
+	CipherXChaCha20Poly1305 = "xchacha20-poly1305"
+)
+
+// Default scrypt cost parameters, as recommended for interactive logins.
+const (
+	DefaultN = 1 << 15
+	DefaultR = 8
+	DefaultP = 1
+)
+
+// Limits on the scrypt cost parameters, so that sealed data read from disk
+// cannot make `Open` use unbounded memory or time. Deriving a key uses 128*N*r
+// bytes of memory and time proportional to N*r*p.
+const (
+	maxN      = 1 << 20
+	maxMemory = 1 << 30
+	maxWork   = 1 << 24
+)
+
+const saltSize = 16
+
+// ErrIncorrectPassphrase is returned by `Open` when the passphrase does not
+// decrypt the data, either because it is wrong or the data was tampered with.
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
+// Sealed is data encrypted with a key derived from a passphrase. It records
+// the parameters needed to derive the key again, so it can be stored as is.
+type Sealed struct {
+	KDF        string
+	Salt       []byte
+	N          int64
+	R          int64
+	P          int64
+	Cipher     string
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+type config struct {
+	n, r, p int
+}
+
+// Option is an option configuring how data is sealed.
+type Option func(cfg *config) error
+
+// WithScryptParams configures the scrypt cost parameters used to derive the
+// key from the passphrase. See `scrypt.Key` for their meaning.
+func WithScryptParams(n, r, p int) Option {
+	return func(cfg *config) error {
+		if err := checkScryptParams(int64(n), int64(r), int64(p)); err != nil {
+			return err
+		}
+		cfg.n, cfg.r, cfg.p = n, r, p
+		return nil
+	}
+}
+
+// checkScryptParams returns an error if the scrypt cost parameters are invalid
+// or exceed the limits on memory and time.
+func checkScryptParams(n, r, p int64) error {
+	if n <= 1 || n&(n-1) != 0 {
+		return fmt.Errorf("scrypt N must be a power of 2 greater than 1: %d", n)
+	}
+	if n > maxN {
+		return fmt.Errorf("scrypt N must be at most %d: %d", maxN, n)
+	}
+	if r <= 0 || p <= 0 {
+		return fmt.Errorf("scrypt r and p must be positive: r=%d p=%d", r, p)
+	}
+	if r > maxWork || p > maxWork || 128*n*r > maxMemory {
+		return fmt.Errorf("scrypt parameters need more than %d bytes of memory: N=%d r=%d", maxMemory, n, r)
+	}
+	if n*r*p > maxWork {
+		return fmt.Errorf("scrypt parameters exceed the maximum cost: N=%d r=%d p=%d", n, r, p)
+	}
+	return nil
+}
+
+// Seal encrypts the plaintext with XChaCha20-Poly1305, using a key derived from
+// the passphrase with scrypt and a random salt.
+func Seal(plaintext []byte, passphrase []byte, options ...Option) (*Sealed, error) {
+	cfg := config{n: DefaultN, r: DefaultR, p: DefaultP}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generating salt: %s", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, cfg.n, cfg.r, cfg.p, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %s", err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %s", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %s", err)
+	}
+
+	return &Sealed{
+		KDF:        KDFScrypt,
+		Salt:       salt,
+		N:          int64(cfg.n),
+		R:          int64(cfg.r),
+		P:          int64(cfg.p),
+		Cipher:     CipherXChaCha20Poly1305,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	}, nil
+}
+
+// Open decrypts data sealed with `Seal`. It returns `ErrIncorrectPassphrase`
+// if the passphrase is not the one the data was sealed with.
+func Open(sealed *Sealed, passphrase []byte) ([]byte, error) {
+	if sealed.KDF != KDFScrypt {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", sealed.KDF)
+	}
+	if sealed.Cipher != CipherXChaCha20Poly1305 {
+		return nil, fmt.Errorf("unsupported cipher: %s", sealed.Cipher)
+	}
+	if err := checkScryptParams(sealed.N, sealed.R, sealed.P); err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(passphrase, sealed.Salt, int(sealed.N), int(sealed.R), int(sealed.P), chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %s", err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %s", err)
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size: %d", len(sealed.Nonce))
+	}
+
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,88 @@
+package keystore_test
+
+import (
+	"testing"
+
+	"github.com/storacha/go-w3up/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+// fast scrypt parameters for tests
+var fast = keystore.WithScryptParams(1<<4, 8, 1)
+
+func TestSealOpen(t *testing.T) {
+	plaintext := []byte("secret key")
+	passphrase := []byte("correct horse battery staple")
+
+	t.Run("round trip", func(t *testing.T) {
+		sealed, err := keystore.Seal(plaintext, passphrase, fast)
+		require.NoError(t, err)
+		require.NotContains(t, string(sealed.Ciphertext), string(plaintext))
+
+		opened, err := keystore.Open(sealed, passphrase)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, opened)
+	})
+
+	t.Run("random salt and nonce", func(t *testing.T) {
+		a, err := keystore.Seal(plaintext, passphrase, fast)
+		require.NoError(t, err)
+		b, err := keystore.Seal(plaintext, passphrase, fast)
+		require.NoError(t, err)
+		require.NotEqual(t, a.Salt, b.Salt)
+		require.NotEqual(t, a.Nonce, b.Nonce)
+		require.NotEqual(t, a.Ciphertext, b.Ciphertext)
+	})
+
+	t.Run("incorrect passphrase", func(t *testing.T) {
+		sealed, err := keystore.Seal(plaintext, passphrase, fast)
+		require.NoError(t, err)
+
+		_, err = keystore.Open(sealed, []byte("wrong"))
+		require.ErrorIs(t, err, keystore.ErrIncorrectPassphrase)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		sealed, err := keystore.Seal(plaintext, passphrase, fast)
+		require.NoError(t, err)
+
+		sealed.Ciphertext[0] ^= 1
+		_, err = keystore.Open(sealed, passphrase)
+		require.ErrorIs(t, err, keystore.ErrIncorrectPassphrase)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := keystore.Seal(plaintext, passphrase, keystore.WithScryptParams(3, 8, 1))
+		require.Error(t, err)
+
+		sealed, err := keystore.Seal(plaintext, passphrase, fast)
+		require.NoError(t, err)
+		sealed.KDF = "pbkdf2"
+		_, err = keystore.Open(sealed, passphrase)
+		require.ErrorContains(t, err, "unsupported key derivation function")
+	})
+
+	t.Run("params out of range", func(t *testing.T) {
+		_, err := keystore.Seal(plaintext, passphrase, keystore.WithScryptParams(1<<21, 8, 1))
+		require.Error(t, err)
+
+		for _, params := range []struct{ n, r, p int64 }{
+			{0, 8, 1},
+			{1 << 21, 8, 1},
+			{1 << 62, 8, 1},
+			{1 << 15, 0, 1},
+			{1 << 15, -8, 1},
+			{1 << 15, 8, -1},
+			{1 << 20, 16, 1},
+			{1 << 15, 1 << 40, 1},
+			{1 << 15, 8, 1 << 40},
+			{1 << 15, 8, 1 << 10},
+		} {
+			sealed, err := keystore.Seal(plaintext, passphrase, fast)
+			require.NoError(t, err)
+			sealed.N, sealed.R, sealed.P = params.n, params.r, params.p
+			_, err = keystore.Open(sealed, passphrase)
+			require.ErrorContains(t, err, "scrypt", "N=%d r=%d p=%d", params.n, params.r, params.p)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package proof
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+//go:embed index.ipldsch
+var indexsch []byte
+
+// Entry is the index entry for a delegation in an `FSStore`. It allows
+// delegations to be listed without reading them from disk.
+type Entry struct {
+	Link         ipld.Link
+	Issuer       string
+	Audience     string
+	Capabilities []Capability
+	// Expiration is the expiry of the delegation in UTC seconds since the Unix
+	// epoch. It is nil if the delegation does not expire.
+	Expiration *int
+	// Revoked is the time the delegation was marked as revoked in UTC seconds
+	// since the Unix epoch. It is nil if the delegation has not been revoked.
+	Revoked *int
+}
+
+// Capability is a capability delegated by a delegation in an `FSStore`.
+type Capability struct {
+	Can  string
+	With string
+}
+
+type indexModel struct {
+	Entries []Entry
+}
+
+// FSStore is a `Store` that persists delegations to a directory. Each
+// delegation is stored as a CAR archive named by its CID, and an index of the
+// delegations, their audience, capabilities and expiry is kept alongside.
+type FSStore struct {
+	mu  sync.RWMutex
+	dir string
+	typ schema.Type
+	idx indexModel
+}
+
+var _ Store = (*FSStore)(nil)
+
+// NewFSStore opens the delegation store in the passed directory, creating it
+// if it does not exist.
+func NewFSStore(dir string) (*FSStore, error) {
+	ts, err := ipldprime.LoadSchemaBytes(indexsch)
+	if err != nil {
+		return nil, fmt.Errorf("loading index schema: %s", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating store directory: %s", err)
+	}
+
+	s := &FSStore{dir: dir, typ: ts.TypeByName("Index")}
+	b, err := os.ReadFile(s.indexPath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("reading index: %s", err)
+		}
+	} else {
+		if _, err := ipldprime.Unmarshal(b, dagcbor.Decode, &s.idx, s.typ); err != nil {
+			return nil, fmt.Errorf("decoding index: %s", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *FSStore) indexPath() string {
+	return filepath.Join(s.dir, "index")
+}
+
+func (s *FSStore) archivePath(link ipld.Link) string {
+	return filepath.Join(s.dir, link.String()+".car")
+}
+
+func (s *FSStore) Add(dlgs ...delegation.Delegation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := indexModel{Entries: append([]Entry{}, s.idx.Entries...)}
+	for _, d := range dlgs {
+		if s.find(d.Link()) >= 0 {
+			continue
+		}
+		b, err := io.ReadAll(d.Archive())
+		if err != nil {
+			return fmt.Errorf("archiving delegation: %s", err)
+		}
+		if err := writeFile(s.archivePath(d.Link()), b); err != nil {
+			return fmt.Errorf("writing delegation: %s", err)
+		}
+		idx.Entries = append(idx.Entries, newEntry(d))
+	}
+	return s.writeIndex(idx)
+}
+
+func (s *FSStore) Get(link ipld.Link) (delegation.Delegation, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.find(link) < 0 {
+		return nil, false, nil
+	}
+	d, err := s.read(link)
+	if err != nil {
+		return nil, false, err
+	}
+	return d, true, nil
+}
+
+func (s *FSStore) All() iter.Seq2[delegation.Delegation, error] {
+	entries := s.Entries()
+	return func(yield func(delegation.Delegation, error) bool) {
+		for _, e := range entries {
+			d, err := s.read(e.Link)
+			if !yield(d, err) {
+				return
+			}
+		}
+	}
+}
+
+func (s *FSStore) Remove(link ipld.Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(link)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, link)
+	}
+
+	idx := indexModel{Entries: append(append([]Entry{}, s.idx.Entries[:i]...), s.idx.Entries[i+1:]...)}
+	if err := s.writeIndex(idx); err != nil {
+		return err
+	}
+	if err := os.Remove(s.archivePath(link)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing delegation: %s", err)
+	}
+	return nil
+}
+
+func (s *FSStore) Revoke(link ipld.Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(link)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, link)
+	}
+	if s.idx.Entries[i].Revoked != nil {
+		return nil
+	}
+
+	idx := indexModel{Entries: append([]Entry{}, s.idx.Entries...)}
+	now := int(ucan.Now())
+	idx.Entries[i].Revoked = &now
+	return s.writeIndex(idx)
+}
+
+func (s *FSStore) Revoked(link ipld.Link) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.find(link)
+	return i >= 0 && s.idx.Entries[i].Revoked != nil, nil
+}
+
+// Entries returns the index entries for the delegations in the store, in the
+// order they were added.
+func (s *FSStore) Entries() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Entry{}, s.idx.Entries...)
+}
+
+func (s *FSStore) find(link ipld.Link) int {
+	for i, e := range s.idx.Entries {
+		if e.Link.String() == link.String() {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *FSStore) read(link ipld.Link) (delegation.Delegation, error) {
+	b, err := os.ReadFile(s.archivePath(link))
+	if err != nil {
+		return nil, fmt.Errorf("reading delegation %s: %s", link, err)
+	}
+	d, err := delegation.Extract(b)
+	if err != nil {
+		return nil, fmt.Errorf("extracting delegation %s: %s", link, err)
+	}
+	return d, nil
+}
+
+func (s *FSStore) writeIndex(idx indexModel) error {
+	b, err := ipldprime.Marshal(dagcbor.Encode, &idx, s.typ)
+	if err != nil {
+		return fmt.Errorf("encoding index: %s", err)
+	}
+	if err := writeFile(s.indexPath(), b); err != nil {
+		return fmt.Errorf("writing index: %s", err)
+	}
+	s.idx = idx
+	return nil
+}
+
+func newEntry(d delegation.Delegation) Entry {
+	e := Entry{
+		Link:       d.Link(),
+		Issuer:     d.Issuer().DID().String(),
+		Audience:   d.Audience().DID().String(),
+		Expiration: d.Expiration(),
+	}
+	for _, c := range d.Capabilities() {
+		e.Capabilities = append(e.Capabilities, Capability{Can: c.Can(), With: c.With()})
+	}
+	return e
+}
+
+// writeFile writes the file atomically, by writing to a temporary file in the
+// same directory and renaming it.
+func writeFile(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, bytes.NewReader(b)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,80 @@
+package proof_test
+
+import (
+	"testing"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSStore(t *testing.T) {
+	dir := t.TempDir()
+	resource := fixtures.Alice.DID().String()
+
+	exp := int(ucan.Now()) + 60
+	a := delegate(t, fixtures.Alice, fixtures.Bob, "upload/*", resource, delegation.WithExpiration(exp))
+	b := delegate(t, fixtures.Alice, fixtures.Bob, "store/add", resource, delegation.WithNoExpiration())
+
+	store, err := proof.NewFSStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Add(a, b))
+	require.NoError(t, store.Add(a))
+
+	// reopen the store to read from disk
+	store, err = proof.NewFSStore(dir)
+	require.NoError(t, err)
+
+	entries := store.Entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, a.Link().String(), entries[0].Link.String())
+	require.Equal(t, fixtures.Alice.DID().String(), entries[0].Issuer)
+	require.Equal(t, fixtures.Bob.DID().String(), entries[0].Audience)
+	require.Equal(t, []proof.Capability{{Can: "upload/*", With: resource}}, entries[0].Capabilities)
+	require.Equal(t, exp, *entries[0].Expiration)
+	require.Nil(t, entries[1].Expiration)
+
+	d, ok, err := store.Get(b.Link())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, b.Link(), d.Link())
+
+	var all []delegation.Delegation
+	for d, err := range store.All() {
+		require.NoError(t, err)
+		all = append(all, d)
+	}
+	require.Equal(t, links([]delegation.Delegation{a, b}), links(all))
+
+	selected, err := proof.Select(store, fixtures.Bob.DID(), "upload/list", resource)
+	require.NoError(t, err)
+	require.Equal(t, links([]delegation.Delegation{a}), links(selected))
+
+	require.NoError(t, store.Revoke(b.Link()))
+	require.ErrorIs(t, store.Revoke(delegate(t, fixtures.Alice, fixtures.Bob, "*", resource).Link()), proof.ErrNotFound)
+
+	// revocation is persisted
+	store, err = proof.NewFSStore(dir)
+	require.NoError(t, err)
+	revoked, err := store.Revoked(b.Link())
+	require.NoError(t, err)
+	require.True(t, revoked)
+	require.NotNil(t, store.Entries()[1].Revoked)
+	require.Nil(t, store.Entries()[0].Revoked)
+
+	selected, err = proof.Select(store, fixtures.Bob.DID(), "store/add", resource)
+	require.NoError(t, err)
+	require.Empty(t, selected)
+
+	require.NoError(t, store.Remove(a.Link()))
+	require.ErrorIs(t, store.Remove(a.Link()), proof.ErrNotFound)
+
+	store, err = proof.NewFSStore(dir)
+	require.NoError(t, err)
+	require.Len(t, store.Entries(), 1)
+	_, ok, err = store.Get(a.Link())
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,238 @@
+package proof
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// AttestAbility is the ability of delegations issued by the service to attest
+// that a delegation issued by an account (e.g. `did:mailto:`) is authorized.
+const AttestAbility = "ucan/attest"
+
+// MaxDepth is the maximum length of a delegation chain that is followed when
+// selecting proofs, and that is validated by `delegation.Validate`.
+const MaxDepth = 32
+
+// Select returns the delegations from the store that prove the audience may
+// invoke the ability on the resource. A delegation is selected if it is issued
+// to the audience, is valid now, has not been revoked, delegates a capability
+// matching the ability (including `*` and `<namespace>/*` wildcards) on the
+// resource (or `ucan:*`), and is either issued by the resource itself or is
+// backed by proofs that form a valid chain to the resource.
+//
+// Attestations in the store for the selected delegations are also returned.
+func Select(store Store, audience did.DID, ability string, resource string) ([]delegation.Delegation, error) {
+	now := ucan.Now()
+
+	var selected []delegation.Delegation
+	for dlg, err := range store.All() {
+		if err != nil {
+			return nil, fmt.Errorf("reading delegations: %s", err)
+		}
+		ok, err := proves(store, dlg, audience.String(), ability, resource, now, 0)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, dlg)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	attestations, err := selectAttestations(store, audience, selected, now)
+	if err != nil {
+		return nil, err
+	}
+	return append(selected, attestations...), nil
+}
+
+// proves reports whether the delegation proves the audience may invoke the
+// ability on the resource.
+func proves(store Store, dlg delegation.Delegation, audience string, ability string, resource string, now ucan.UTCUnixTimestamp, depth int) (bool, error) {
+	if depth > MaxDepth {
+		return false, nil
+	}
+	if dlg.Audience().DID().String() != audience || !isActive(dlg, now) {
+		return false, nil
+	}
+	if revoked, err := store.Revoked(dlg.Link()); err != nil || revoked {
+		return false, err
+	}
+
+	issuer := dlg.Issuer().DID().String()
+	for _, cap := range dlg.Capabilities() {
+		if !AbilityMatches(cap.Can(), ability) {
+			continue
+		}
+		if cap.With() != resource && cap.With() != "ucan:*" {
+			continue
+		}
+		// the resource is the root authority
+		if issuer == resource {
+			return true, nil
+		}
+		for _, link := range dlg.Proofs() {
+			prf, ok, err := resolveProof(store, dlg, link)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue
+			}
+			ok, err = proves(store, prf, issuer, ability, resource, now, depth+1)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Path returns the chain of proofs that links the delegation to a delegation
+// issued by the issuer, starting with the proof of the delegation and ending
+// with the delegation issued by the issuer. It is empty if the delegation is
+// itself issued by the issuer. It returns false if the issuer does not appear in
+// the proof chain of the delegation.
+func Path(store Store, dlg delegation.Delegation, issuer did.DID) ([]delegation.Delegation, bool, error) {
+	return path(store, dlg, issuer, 0)
+}
+
+func path(store Store, dlg delegation.Delegation, issuer did.DID, depth int) ([]delegation.Delegation, bool, error) {
+	if dlg.Issuer().DID() == issuer {
+		return nil, true, nil
+	}
+	if depth > MaxDepth {
+		return nil, false, nil
+	}
+	for _, link := range dlg.Proofs() {
+		prf, ok, err := resolveProof(store, dlg, link)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+		rest, ok, err := path(store, prf, issuer, depth+1)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return append([]delegation.Delegation{prf}, rest...), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// AbilityMatches reports whether a delegated ability, which may be a wildcard
+// such as `*` or `upload/*`, covers the passed ability.
+func AbilityMatches(delegated string, ability string) bool {
+	if delegated == "*" || delegated == ability {
+		return true
+	}
+	if ns, ok := strings.CutSuffix(delegated, "/*"); ok {
+		return strings.HasPrefix(ability, ns+"/")
+	}
+	return false
+}
+
+func isActive(dlg delegation.Delegation, now ucan.UTCUnixTimestamp) bool {
+	if exp := dlg.Expiration(); exp != nil && *exp <= now {
+		return false
+	}
+	return dlg.NotBefore() <= now
+}
+
+// resolveProof finds a proof of the delegation, either in the blocks of the
+// delegation or in the store.
+func resolveProof(store Store, dlg delegation.Delegation, link ipld.Link) (delegation.Delegation, bool, error) {
+	bs, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(dlg.Blocks()))
+	if err != nil {
+		return nil, false, fmt.Errorf("creating block reader: %s", err)
+	}
+	if _, ok, _ := bs.Get(link); ok {
+		prf, err := delegation.NewDelegationView(link, bs)
+		if err != nil {
+			return nil, false, fmt.Errorf("reading proof %s: %s", link, err)
+		}
+		return prf, true, nil
+	}
+	return store.Get(link)
+}
+
+// selectAttestations returns the attestations in the store that are issued to
+// the audience for the selected delegations.
+func selectAttestations(store Store, audience did.DID, selected []delegation.Delegation, now ucan.UTCUnixTimestamp) ([]delegation.Delegation, error) {
+	links := map[string]struct{}{}
+	for _, dlg := range selected {
+		links[dlg.Link().String()] = struct{}{}
+	}
+
+	var attestations []delegation.Delegation
+	for dlg, err := range store.All() {
+		if err != nil {
+			return nil, fmt.Errorf("reading delegations: %s", err)
+		}
+		if dlg.Audience().DID() != audience || !isActive(dlg, now) {
+			continue
+		}
+		for _, cap := range dlg.Capabilities() {
+			if cap.Can() != AttestAbility {
+				continue
+			}
+			prf, ok := attestedProof(cap)
+			if !ok {
+				continue
+			}
+			if _, ok := links[prf.String()]; ok {
+				attestations = append(attestations, dlg)
+				break
+			}
+		}
+	}
+	return attestations, nil
+}
+
+// Attests returns the links to the delegations the passed delegation attests
+// to with `ucan/attest` capabilities.
+func Attests(dlg delegation.Delegation) []ipld.Link {
+	var links []ipld.Link
+	for _, cap := range dlg.Capabilities() {
+		if cap.Can() != AttestAbility {
+			continue
+		}
+		if link, ok := attestedProof(cap); ok {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// attestedProof returns the link to the delegation an attestation capability
+// attests to.
+func attestedProof(cap ucan.Capability[any]) (ipld.Link, bool) {
+	nb, ok := cap.Nb().(ipld.Node)
+	if !ok {
+		return nil, false
+	}
+	n, err := nb.LookupByString("proof")
+	if err != nil {
+		return nil, false
+	}
+	link, err := n.AsLink()
+	if err != nil {
+		return nil, false
+	}
+	return link, true
+}
@@ -0,0 +1,199 @@
+package proof_test
+
+import (
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	psigner "github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-w3up/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func delegate(t *testing.T, issuer principal.Signer, audience ucan.Principal, can string, with string, options ...delegation.Option) delegation.Delegation {
+	t.Helper()
+	return helpers.Must(delegation.Delegate(
+		issuer,
+		audience,
+		[]ucan.Capability[ucan.NoCaveats]{ucan.NewCapability(can, with, ucan.NoCaveats{})},
+		options...,
+	))
+}
+
+type attestCaveat struct {
+	proof ipld.Link
+}
+
+func (c attestCaveat) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "proof", qp.Link(c.proof))
+	})
+}
+
+func links(dlgs []delegation.Delegation) []string {
+	var ls []string
+	for _, d := range dlgs {
+		ls = append(ls, d.Link().String())
+	}
+	return ls
+}
+
+func TestSelect(t *testing.T) {
+	space := helpers.Must(signer.Generate())
+	resource := space.DID().String()
+	agent := fixtures.Bob
+
+	t.Run("direct", func(t *testing.T) {
+		dlg := delegate(t, space, agent, "upload/add", resource)
+		store := proof.NewMemoryStore(dlg)
+
+		selected, err := proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg}), links(selected))
+
+		selected, err = proof.Select(store, agent.DID(), "upload/list", resource)
+		require.NoError(t, err)
+		require.Empty(t, selected)
+	})
+
+	t.Run("wildcards", func(t *testing.T) {
+		ns := delegate(t, space, agent, "upload/*", resource)
+		all := delegate(t, space, agent, "*", resource)
+		store := proof.NewMemoryStore(ns, all)
+
+		selected, err := proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{ns, all}), links(selected))
+
+		selected, err = proof.Select(store, agent.DID(), "store/add", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{all}), links(selected))
+
+		// `upload/*` does not match a similarly prefixed namespace
+		require.False(t, proof.AbilityMatches("upload/*", "uploads/add"))
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		root := delegate(t, space, fixtures.Alice, "upload/*", resource)
+		dlg := delegate(t, fixtures.Alice, agent, "upload/add", resource, delegation.WithProof(delegation.FromDelegation(root)))
+		store := proof.NewMemoryStore(root, dlg)
+
+		selected, err := proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg}), links(selected))
+
+		// proof not delegated by the resource
+		bogus := delegate(t, fixtures.Mallory, fixtures.Alice, "upload/*", resource)
+		dlg = delegate(t, fixtures.Alice, agent, "upload/add", resource, delegation.WithProof(delegation.FromDelegation(bogus)))
+		store = proof.NewMemoryStore(dlg)
+
+		selected, err = proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Empty(t, selected)
+	})
+
+	t.Run("proof in store", func(t *testing.T) {
+		root := delegate(t, space, fixtures.Alice, "upload/*", resource)
+		// only the link to the proof is included in the delegation
+		dlg := delegate(t, fixtures.Alice, agent, "upload/add", resource, delegation.WithProof(delegation.FromLink(root.Link())))
+
+		selected, err := proof.Select(proof.NewMemoryStore(dlg), agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Empty(t, selected)
+
+		selected, err = proof.Select(proof.NewMemoryStore(dlg, root), agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg}), links(selected))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := delegate(t, space, agent, "upload/add", resource, delegation.WithExpiration(int(ucan.Now())-60))
+		future := delegate(t, space, agent, "upload/add", resource, delegation.WithNotBefore(int(ucan.Now())+60))
+		store := proof.NewMemoryStore(expired, future)
+
+		selected, err := proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Empty(t, selected)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		root := delegate(t, space, fixtures.Alice, "upload/*", resource)
+		dlg := delegate(t, fixtures.Alice, agent, "upload/add", resource, delegation.WithProof(delegation.FromLink(root.Link())))
+		direct := delegate(t, space, agent, "upload/add", resource)
+		store := proof.NewMemoryStore(root, dlg, direct)
+		require.NoError(t, store.Revoke(direct.Link()))
+
+		selected, err := proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg}), links(selected))
+
+		// revoking a proof in the chain invalidates the delegation
+		require.NoError(t, store.Revoke(root.Link()))
+		selected, err = proof.Select(store, agent.DID(), "upload/add", resource)
+		require.NoError(t, err)
+		require.Empty(t, selected)
+
+		require.ErrorIs(t, store.Revoke(delegate(t, space, agent, "store/add", resource).Link()), proof.ErrNotFound)
+	})
+
+	t.Run("account with attestation", func(t *testing.T) {
+		account := helpers.Must(did.Parse("did:mailto:example.com:alice"))
+		accountSigner := helpers.Must(signer.Generate())
+		wrapped := helpers.Must(psigner.Wrap(accountSigner, account))
+
+		root := delegate(t, space, wrapped, "*", resource)
+		dlg := delegate(t, wrapped, agent, "*", "ucan:*", delegation.WithProof(delegation.FromDelegation(root)))
+		attestation := helpers.Must(delegation.Delegate(
+			fixtures.Service,
+			agent,
+			[]ucan.Capability[attestCaveat]{
+				ucan.NewCapability(proof.AttestAbility, fixtures.Service.DID().String(), attestCaveat{dlg.Link()}),
+			},
+		))
+		other := helpers.Must(delegation.Delegate(
+			fixtures.Service,
+			agent,
+			[]ucan.Capability[attestCaveat]{
+				ucan.NewCapability(proof.AttestAbility, fixtures.Service.DID().String(), attestCaveat{root.Link()}),
+			},
+		))
+		store := proof.NewMemoryStore(dlg, attestation, other)
+
+		selected, err := proof.Select(store, agent.DID(), "upload/list", resource)
+		require.NoError(t, err)
+		require.Equal(t, links([]delegation.Delegation{dlg, attestation}), links(selected))
+	})
+}
+
+func TestPath(t *testing.T) {
+	space := helpers.Must(signer.Generate())
+	resource := space.DID().String()
+
+	root := delegate(t, space, fixtures.Alice, "*", resource)
+	mid := delegate(t, fixtures.Alice, fixtures.Bob, "upload/*", resource, delegation.WithProof(delegation.FromDelegation(root)))
+	leaf := delegate(t, fixtures.Bob, fixtures.Mallory, "upload/add", resource, delegation.WithProof(delegation.FromLink(mid.Link())))
+	store := proof.NewMemoryStore(mid)
+
+	chain, ok, err := proof.Path(store, leaf, fixtures.Bob.DID())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, chain)
+
+	chain, ok, err = proof.Path(store, leaf, space.DID())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, links([]delegation.Delegation{mid, root}), links(chain))
+
+	_, ok, err = proof.Path(store, leaf, fixtures.Mallory.DID())
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+)
+
+// ErrNotFound is returned when removing a delegation that is not in a store.
+var ErrNotFound = errors.New("delegation not found")
+
+// Store is a store of delegations that proofs for invocations are selected
+// from.
+type Store interface {
+	// Add adds delegations to the store. Adding a delegation that is already in
+	// the store has no effect.
+	Add(dlgs ...delegation.Delegation) error
+	// Get returns the delegation with the passed CID, if it is in the store.
+	Get(link ipld.Link) (delegation.Delegation, bool, error)
+	// All returns an iterator over the delegations in the store, in the order
+	// they were added.
+	All() iter.Seq2[delegation.Delegation, error]
+	// Remove removes the delegation with the passed CID from the store. It
+	// returns `ErrNotFound` if the delegation is not in the store.
+	Remove(link ipld.Link) error
+	// Revoke marks the delegation with the passed CID as revoked. Revoked
+	// delegations remain in the store but are not selected as proofs. It returns
+	// `ErrNotFound` if the delegation is not in the store.
+	Revoke(link ipld.Link) error
+	// Revoked reports whether the delegation with the passed CID has been marked
+	// as revoked.
+	Revoked(link ipld.Link) (bool, error)
+}
+
+// MemoryStore is a `Store` that holds delegations in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	keys    []string
+	items   map[string]delegation.Delegation
+	revoked map[string]struct{}
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a new in-memory delegation store, optionally
+// populated with the passed delegations.
+func NewMemoryStore(dlgs ...delegation.Delegation) *MemoryStore {
+	s := &MemoryStore{items: map[string]delegation.Delegation{}, revoked: map[string]struct{}{}}
+	s.Add(dlgs...)
+	return s
+}
+
+func (s *MemoryStore) Add(dlgs ...delegation.Delegation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range dlgs {
+		key := d.Link().String()
+		if _, ok := s.items[key]; ok {
+			continue
+		}
+		s.keys = append(s.keys, key)
+		s.items[key] = d
+	}
+	return nil
+}
+
+func (s *MemoryStore) Get(link ipld.Link) (delegation.Delegation, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.items[link.String()]
+	return d, ok, nil
+}
+
+func (s *MemoryStore) All() iter.Seq2[delegation.Delegation, error] {
+	s.mu.RLock()
+	dlgs := make([]delegation.Delegation, 0, len(s.keys))
+	for _, k := range s.keys {
+		dlgs = append(dlgs, s.items[k])
+	}
+	s.mu.RUnlock()
+
+	return func(yield func(delegation.Delegation, error) bool) {
+		for _, d := range dlgs {
+			if !yield(d, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *MemoryStore) Remove(link ipld.Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := link.String()
+	if _, ok := s.items[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, link)
+	}
+	delete(s.items, key)
+	delete(s.revoked, key)
+	s.keys = slices.DeleteFunc(s.keys, func(k string) bool { return k == key })
+	return nil
+}
+
+func (s *MemoryStore) Revoke(link ipld.Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := link.String()
+	if _, ok := s.items[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, link)
+	}
+	s.revoked[key] = struct{}{}
+	return nil
+}
+
+func (s *MemoryStore) Revoked(link ipld.Link) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[link.String()]
+	return ok, nil
+}
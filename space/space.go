@@ -0,0 +1,98 @@
+package space
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Generate creates a new ed25519 space key. The DID of the key is the DID of
+// the space.
+func Generate() (principal.Signer, error) {
+	return signer.Generate()
+}
+
+// ToMnemonic encodes the seed of an ed25519 space key as a BIP39 mnemonic,
+// which can be used to recover the space key with `FromMnemonic`.
+func ToMnemonic(space principal.Signer) (string, error) {
+	if space.Code() != signer.Code {
+		return "", fmt.Errorf("unsupported space key type: %d", space.Code())
+	}
+	return bip39.NewMnemonic(space.Raw()[:ed25519.SeedSize])
+}
+
+// FromMnemonic recovers an ed25519 space key from a BIP39 mnemonic created
+// with `ToMnemonic`.
+func FromMnemonic(mnemonic string) (principal.Signer, error) {
+	seed, err := bip39.EntropyFromMnemonic(strings.Join(strings.Fields(mnemonic), " "))
+	if err != nil {
+		return nil, fmt.Errorf("decoding mnemonic: %s", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid mnemonic length: %d words", len(strings.Fields(mnemonic)))
+	}
+	return signer.FromRaw(ed25519.NewKeyFromSeed(seed))
+}
+
+// Delegate creates a delegation of full authority (`*`) over the space to the
+// agent, issued by the space itself. It does not expire. The space name is
+// included in the delegation facts so it can be read with `Name`.
+func Delegate(space principal.Signer, agent ucan.Principal, name string) (delegation.Delegation, error) {
+	return delegation.Delegate(
+		space,
+		agent,
+		[]ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability("*", space.DID().String(), ucan.NoCaveats{}),
+		},
+		delegation.WithNoExpiration(),
+		delegation.WithFacts([]ucan.FactBuilder{nameFact{name}}),
+	)
+}
+
+type nameFact struct {
+	name string
+}
+
+func (f nameFact) ToIPLD() (map[string]datamodel.Node, error) {
+	n, err := qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "name", qp.String(f.name))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]datamodel.Node{"space": n}, nil
+}
+
+// Name returns the space name from the facts of a delegation created with
+// `Delegate`.
+func Name(dlg delegation.Delegation) (string, bool) {
+	for _, f := range dlg.Facts() {
+		s, ok := f["space"]
+		if !ok {
+			continue
+		}
+		n, ok := s.(datamodel.Node)
+		if !ok {
+			continue
+		}
+		name, err := n.LookupByString("name")
+		if err != nil {
+			continue
+		}
+		str, err := name.AsString()
+		if err != nil {
+			continue
+		}
+		return str, true
+	}
+	return "", false
+}
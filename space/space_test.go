@@ -0,0 +1,60 @@
+package space_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/testing/fixtures"
+	"github.com/storacha/go-ucanto/testing/helpers"
+	"github.com/storacha/go-w3up/space"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMnemonic(t *testing.T) {
+	s := helpers.Must(space.Generate())
+
+	mnemonic, err := space.ToMnemonic(s)
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(mnemonic), 24)
+
+	recovered, err := space.FromMnemonic(mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, s.DID(), recovered.DID())
+	require.Equal(t, s.Encode(), recovered.Encode())
+
+	// extra whitespace is ignored
+	recovered, err = space.FromMnemonic("  " + strings.ReplaceAll(mnemonic, " ", "\n") + "\n")
+	require.NoError(t, err)
+	require.Equal(t, s.DID(), recovered.DID())
+
+	// bad checksum
+	_, err = space.FromMnemonic(strings.Repeat("abandon ", 24))
+	require.Error(t, err)
+
+	// too short for an ed25519 seed
+	_, err = space.FromMnemonic(strings.Repeat("abandon ", 11) + "about")
+	require.Error(t, err)
+}
+
+func TestDelegate(t *testing.T) {
+	s := helpers.Must(space.Generate())
+
+	dlg, err := space.Delegate(s, fixtures.Alice, "my space")
+	require.NoError(t, err)
+	require.Equal(t, s.DID(), dlg.Issuer().DID())
+	require.Equal(t, fixtures.Alice.DID(), dlg.Audience().DID())
+	require.Nil(t, dlg.Expiration())
+	require.Equal(t, "*", dlg.Capabilities()[0].Can())
+	require.Equal(t, s.DID().String(), dlg.Capabilities()[0].With())
+
+	// name survives a round trip through an archive
+	b := helpers.Must(io.ReadAll(dlg.Archive()))
+	dlg, err = delegation.Extract(b)
+	require.NoError(t, err)
+
+	name, ok := space.Name(dlg)
+	require.True(t, ok)
+	require.Equal(t, "my space", name)
+}
@@ -0,0 +1,105 @@
+package unixfs
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/multiformats/go-multicodec"
+	"github.com/spaolacci/murmur3"
+)
+
+// DefaultShardThreshold is the number of entries above which directories are
+// encoded as HAMT shards, matching the w3up JS client.
+const DefaultShardThreshold = 1000
+
+// hamtFanout is the number of slots in each HAMT shard node. Each level of the
+// HAMT consumes 8 bits of the hash of an entry name.
+const hamtFanout = 256
+
+// WithShardThreshold configures the number of entries above which a directory
+// is encoded as a HAMT sharded directory - default 1000.
+func WithShardThreshold(n int) Option {
+	return func(cfg *importerConfig) error {
+		if n < 0 {
+			return fmt.Errorf("shard threshold must not be negative: %d", n)
+		}
+		cfg.shardThreshold = n
+		return nil
+	}
+}
+
+// hashedEntry is a directory entry with the hash of its name.
+type hashedEntry struct {
+	entry
+	hash [8]byte
+}
+
+// encodeShardedDirectory encodes a directory as a HAMT sharded directory, as
+// specified by https://github.com/ipfs/specs/blob/main/UNIXFS.md and
+// implemented by kubo. Entries are placed in slots by the murmur3 x64 64 bit
+// hash of their name. Slots with a single entry link to it directly, and slots
+// with several link to a child shard of them. The tests check the encoding
+// against kubo, and against the w3up JS client once its fixtures have been
+// generated.
+func encodeShardedDirectory(entries []entry, yield yieldFunc) (node, error) {
+	hashed := make([]hashedEntry, 0, len(entries))
+	for _, e := range entries {
+		h := hashedEntry{entry: e}
+		binary.BigEndian.PutUint64(h.hash[:], murmur3.Sum64([]byte(e.name)))
+		hashed = append(hashed, h)
+	}
+	return encodeShard(hashed, 0, yield)
+}
+
+func encodeShard(entries []hashedEntry, depth int, yield yieldFunc) (node, error) {
+	if depth >= len(hashedEntry{}.hash) {
+		return node{}, fmt.Errorf("sharded directory too deep: entry names have colliding hashes")
+	}
+
+	var slots [hamtFanout][]hashedEntry
+	for _, e := range entries {
+		idx := e.hash[depth]
+		slots[idx] = append(slots[idx], e)
+	}
+
+	// the bitfield is a big endian integer with a bit set for each occupied
+	// slot, without leading zero bytes
+	bitfield := make([]byte, hamtFanout/8)
+	var size, dagSize uint64
+	var links []pbLink
+	for idx, slot := range slots {
+		if len(slot) == 0 {
+			continue
+		}
+		bitfield[len(bitfield)-1-idx/8] |= 1 << (idx % 8)
+
+		prefix := fmt.Sprintf("%02X", idx)
+		if len(slot) == 1 {
+			n := slot[0].node
+			links = append(links, pbLink{Hash: n.link, Name: prefix + slot[0].name, Tsize: n.dagSize})
+			size += n.size
+			dagSize += n.dagSize
+			continue
+		}
+		child, err := encodeShard(slot, depth+1, yield)
+		if err != nil {
+			return node{}, err
+		}
+		links = append(links, pbLink{Hash: child.link, Name: prefix, Tsize: child.dagSize})
+		size += child.size
+		dagSize += child.dagSize
+	}
+	for len(bitfield) > 0 && bitfield[0] == 0 {
+		bitfield = bitfield[1:]
+	}
+
+	hashType := uint64(multicodec.Murmur3X64_64)
+	fanout := uint64(hamtFanout)
+	d := data{Type: typeHAMTShard, Data: bitfield, HashType: &hashType, Fanout: &fanout}
+	b := encodeNode(links, d.encode())
+	c, err := emit(yield, multicodec.DagPb, b)
+	if err != nil {
+		return node{}, err
+	}
+	return node{link: c, size: size, dagSize: dagSize + uint64(len(b))}, nil
+}
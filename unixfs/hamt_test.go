@@ -0,0 +1,50 @@
+package unixfs_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/storacha/go-w3up/unixfs"
+	"github.com/stretchr/testify/require"
+)
+
+func entries(n int) []unixfs.File {
+	var files []unixfs.File
+	for i := 0; i < n; i++ {
+		files = append(files, file(fmt.Sprintf("file-%d.txt", i), []byte(fmt.Sprintf("content %d\n", i))))
+	}
+	return files
+}
+
+func TestEncodeShardedDirectory(t *testing.T) {
+	withFixtures(t, func(t *testing.T, expected fixtures) {
+		blks := collect(t, unixfs.EncodeDirectory(entries(1001)))
+		require.Equal(t, expected.ShardedDirectory, root(blks))
+
+		t.Run("configured threshold", func(t *testing.T) {
+			require.NotEmpty(t, expected.ShardedThreshold4)
+			for n, cid := range expected.ShardedThreshold4 {
+				blks := collect(t, unixfs.EncodeDirectory(entries(n), unixfs.WithShardThreshold(4)))
+				require.Equal(t, cid, root(blks), "%d entries", n)
+			}
+		})
+
+		t.Run("nested", func(t *testing.T) {
+			var files []unixfs.File
+			for _, f := range entries(1001) {
+				f.Path = "big/" + f.Path
+				files = append(files, f)
+			}
+			files = append(files, file("x.txt", []byte("x")))
+
+			blks := collect(t, unixfs.EncodeDirectory(files))
+			require.Equal(t, expected.ShardedNested, root(blks))
+		})
+	})
+
+	t.Run("at default threshold", func(t *testing.T) {
+		blks := collect(t, unixfs.EncodeDirectory(entries(1000)))
+		// a flat directory: the files and the directory node
+		require.Len(t, blks, 1001)
+	})
+}
@@ -0,0 +1,84 @@
+package unixfs
+
+import (
+	"github.com/ipfs/go-cid"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// UnixFS node types, see https://github.com/ipfs/specs/blob/main/UNIXFS.md
+const (
+	typeDirectory = 1
+	typeFile      = 2
+	typeHAMTShard = 5
+)
+
+// data is the UnixFS `Data` protobuf message.
+type data struct {
+	Type       uint64
+	Data       []byte
+	FileSize   *uint64
+	BlockSizes []uint64
+	HashType   *uint64
+	Fanout     *uint64
+}
+
+// encode encodes the message with fields in field number order, as the go and
+// JS UnixFS implementations do. Block sizes are not packed.
+func (d data) encode() []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, d.Type)
+	if d.Data != nil {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, d.Data)
+	}
+	if d.FileSize != nil {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, *d.FileSize)
+	}
+	for _, size := range d.BlockSizes {
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, size)
+	}
+	if d.HashType != nil {
+		b = protowire.AppendTag(b, 5, protowire.VarintType)
+		b = protowire.AppendVarint(b, *d.HashType)
+	}
+	if d.Fanout != nil {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, *d.Fanout)
+	}
+	return b
+}
+
+// pbLink is a dag-pb `PBLink`. Links to file chunks are unnamed.
+type pbLink struct {
+	Hash  cid.Cid
+	Name  string
+	Tsize uint64
+}
+
+// encodeNode encodes a dag-pb `PBNode`. Following the dag-pb spec, links are
+// encoded before the data, in the order passed. Unlike kubo, the name of
+// unnamed links is omitted rather than encoded as an empty string, which is
+// how @ipld/unixfs writes the links of file nodes.
+func encodeNode(links []pbLink, d []byte) []byte {
+	var b []byte
+	for _, l := range links {
+		var lb []byte
+		lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+		lb = protowire.AppendBytes(lb, l.Hash.Bytes())
+		if l.Name != "" {
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Name)
+		}
+		lb = protowire.AppendTag(lb, 3, protowire.VarintType)
+		lb = protowire.AppendVarint(lb, l.Tsize)
+
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, d)
+	return b
+}
@@ -0,0 +1,158 @@
+// Generates ../fixtures.json, the CIDs the unixfs package tests expect, with
+// the w3up JS client:
+//
+//   npm install && npm run generate
+//
+// Cases using the client defaults are encoded with the upload-client encoder
+// streams. Cases with a smaller chunk size, layout width or shard threshold
+// use @ipld/unixfs configured the way upload-client configures it, and build
+// directories the way upload-client does.
+import * as UnixFS from '@ipld/unixfs'
+import { withMaxChunkSize } from '@ipld/unixfs/file/chunker/fixed'
+import { withWidth } from '@ipld/unixfs/file/layout/balanced'
+import * as raw from 'multiformats/codecs/raw'
+import {
+  createFileEncoderStream,
+  createDirectoryEncoderStream,
+} from '@web3-storage/upload-client/unixfs'
+import { createRequire } from 'node:module'
+
+const { version } = createRequire(import.meta.url)(
+  '@web3-storage/upload-client/package.json'
+)
+
+// content returns n bytes of test content, as `content` in the Go tests.
+const content = (n) => {
+  const b = new Uint8Array(n)
+  for (let i = 0; i < n; i++) b[i] = i % 251
+  return b
+}
+
+// entries returns n small named files, as `entries` in the Go tests.
+const entries = (n) =>
+  Array.from({ length: n }, (_, i) => [
+    `file-${i}.txt`,
+    new TextEncoder().encode(`content ${i}\n`),
+  ])
+
+const settings = (chunkSize, width) =>
+  UnixFS.configure({
+    fileChunkEncoder: raw,
+    smallFileEncoder: raw,
+    chunker: withMaxChunkSize(chunkSize),
+    fileLayout: withWidth(width),
+  })
+
+// lastBlock drains a stream of blocks and returns the CID of the last, the
+// root.
+const lastBlock = async (stream) => {
+  let last
+  for await (const block of stream) last = block
+  return last.cid.toString()
+}
+
+// encode runs fn with a UnixFS writer, discarding the blocks it writes, and
+// returns the CID of the link fn returns.
+const encode = async (chunkSize, width, fn) => {
+  const { readable, writable } = new TransformStream(
+    {},
+    UnixFS.withCapacity(1024 * 1024 * 64)
+  )
+  const drained = readable.pipeTo(new WritableStream())
+  const writer = UnixFS.createWriter({
+    writable,
+    settings: settings(chunkSize, width),
+  })
+  const link = await fn(writer)
+  await writer.close()
+  await drained
+  return link.cid.toString()
+}
+
+const writeFile = async (writer, bytes) => {
+  const file = UnixFS.createFileWriter(writer)
+  await file.write(bytes)
+  return file.close()
+}
+
+// tree builds nested maps of file bytes from [path, bytes] pairs.
+const tree = (files) => {
+  const root = new Map()
+  for (const [path, bytes] of files) {
+    const parts = path.split('/')
+    let dir = root
+    for (const name of parts.slice(0, -1)) {
+      if (!dir.has(name)) dir.set(name, new Map())
+      dir = dir.get(name)
+    }
+    dir.set(parts[parts.length - 1], bytes)
+  }
+  return root
+}
+
+// writeTree writes a directory as upload-client does, sharding directories
+// with more than `threshold` entries.
+const writeTree = async (writer, dir, threshold) => {
+  const dirWriter =
+    dir.size <= threshold
+      ? UnixFS.createDirectoryWriter(writer)
+      : UnixFS.createShardedDirectoryWriter(writer)
+  for (const [name, entry] of dir) {
+    const link =
+      entry instanceof Map
+        ? await writeTree(writer, entry, threshold)
+        : await writeFile(writer, entry)
+    dirWriter.set(name, link)
+  }
+  return dirWriter.close()
+}
+
+const fixtures = {
+  generator: `@web3-storage/upload-client ${version}`,
+  file: {},
+}
+
+for (const size of [0, 1, 1024, 1025, 4096, 4097, 16385, 50000]) {
+  fixtures.file[size] = await encode(1024, 4, (w) =>
+    writeFile(w, content(size))
+  )
+}
+
+fixtures.fileDefaults = await lastBlock(
+  createFileEncoderStream(new Blob([content(3 * 1024 * 1024 + 1)]))
+)
+
+const dir = tree([
+  ['b/c.txt', content(10)],
+  ['a.txt', content(5000)],
+  ['b/d.txt', content(3000)],
+  ['empty', content(0)],
+])
+fixtures.directory = await encode(1024, 4, (w) => writeTree(w, dir, 1000))
+fixtures.directorySubdir = await encode(1024, 4, (w) =>
+  writeTree(w, dir.get('b'), 1000)
+)
+
+const toFiles = (pairs) => pairs.map(([path, bytes]) => new File([bytes], path))
+
+fixtures.shardedDirectory = await lastBlock(
+  createDirectoryEncoderStream(toFiles(entries(1001)))
+)
+
+fixtures.shardedThreshold4 = {}
+for (const n of [5, 300]) {
+  fixtures.shardedThreshold4[n] = await encode(1024 * 1024, 1024, (w) =>
+    writeTree(w, tree(entries(n)), 4)
+  )
+}
+
+fixtures.shardedNested = await lastBlock(
+  createDirectoryEncoderStream(
+    toFiles([
+      ...entries(1001).map(([name, bytes]) => [`big/${name}`, bytes]),
+      ['x.txt', new TextEncoder().encode('x')],
+    ])
+  )
+)
+
+console.log(JSON.stringify(fixtures, null, 2))
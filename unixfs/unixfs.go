@@ -0,0 +1,339 @@
+package unixfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/ipld/block"
+)
+
+// DefaultChunkSize is the size of the chunks files are split into, matching
+// the w3up JS client.
+const DefaultChunkSize = 1024 * 1024
+
+// DefaultMaxLinks is the maximum number of links in a file node, i.e. the width
+// of the balanced DAG layout, matching the w3up JS client.
+const DefaultMaxLinks = 1024
+
+// Option is an option configuring the importer.
+type Option func(cfg *importerConfig) error
+
+type importerConfig struct {
+	chunkSize      int
+	maxLinks       int
+	shardThreshold int
+}
+
+// WithChunkSize configures the size of the chunks files are split into -
+// default 1MiB.
+func WithChunkSize(size int) Option {
+	return func(cfg *importerConfig) error {
+		if size <= 0 {
+			return fmt.Errorf("chunk size must be positive: %d", size)
+		}
+		cfg.chunkSize = size
+		return nil
+	}
+}
+
+// WithMaxLinks configures the maximum number of links in a file node - default
+// 1024.
+func WithMaxLinks(n int) Option {
+	return func(cfg *importerConfig) error {
+		if n < 2 {
+			return fmt.Errorf("max links must be at least 2: %d", n)
+		}
+		cfg.maxLinks = n
+		return nil
+	}
+}
+
+func newConfig(options []Option) (importerConfig, error) {
+	cfg := importerConfig{chunkSize: DefaultChunkSize, maxLinks: DefaultMaxLinks, shardThreshold: DefaultShardThreshold}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// File is a file to encode in a directory with `EncodeDirectory`.
+type File struct {
+	// Path is the slash separated path of the file relative to the directory.
+	Path string
+	// Open opens the file for reading. The file is opened when it is encoded
+	// and closed once it has been read.
+	Open func() (io.ReadCloser, error)
+}
+
+// node is an encoded UnixFS node.
+type node struct {
+	link cid.Cid
+	// size is the byte length of the file content under the node.
+	size uint64
+	// dagSize is the byte length of the blocks of the DAG under the node,
+	// including the node itself.
+	dagSize uint64
+}
+
+// EncodeFile encodes the contents read from `r` as a UnixFS file. The file is
+// split into fixed size chunks encoded as raw blocks, which are linked from
+// dag-pb nodes in a balanced DAG layout. A file of a single chunk is encoded as
+// just the raw block. All CIDs are v1.
+//
+// Blocks are yielded as soon as they are encoded, so the file is never held in
+// memory. The last block is the root of the file.
+func EncodeFile(r io.Reader, options ...Option) iter.Seq2[ipld.Block, error] {
+	return func(yield func(ipld.Block, error) bool) {
+		cfg, err := newConfig(options)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		_, err = encodeFile(cfg, r, yield)
+		if err != nil && !errors.Is(err, errStopped) {
+			yield(nil, err)
+		}
+	}
+}
+
+// EncodeDirectory encodes the files as a UnixFS directory. Intermediate
+// directories are created for files in subdirectories. Files are encoded as
+// with `EncodeFile`, in the order passed. Directories with more entries than
+// the shard threshold (see `WithShardThreshold`) are encoded as HAMT sharded
+// directories, so that directory nodes stay small.
+//
+// Blocks are yielded as soon as they are encoded. The last block is the root
+// of the directory.
+func EncodeDirectory(files []File, options ...Option) iter.Seq2[ipld.Block, error] {
+	return func(yield func(ipld.Block, error) bool) {
+		cfg, err := newConfig(options)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		root, err := newTree(files)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		_, err = encodeDirectory(cfg, root, yield)
+		if err != nil && !errors.Is(err, errStopped) {
+			yield(nil, err)
+		}
+	}
+}
+
+// errStopped is returned when the consumer stops iterating over blocks.
+var errStopped = errors.New("iteration stopped")
+
+type yieldFunc = func(ipld.Block, error) bool
+
+func emit(yield yieldFunc, codec multicodec.Code, b []byte) (cid.Cid, error) {
+	digest, err := multihash.Sum(b, multihash.SHA2_256, -1)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("hashing block: %s", err)
+	}
+	c := cid.NewCidV1(uint64(codec), digest)
+	if !yield(block.NewBlock(cidlink.Link{Cid: c}, b), nil) {
+		return cid.Undef, errStopped
+	}
+	return c, nil
+}
+
+func encodeFile(cfg importerConfig, r io.Reader, yield yieldFunc) (node, error) {
+	// levels[0] holds leaves not yet linked from a node, levels[1] nodes
+	// linking to leaves, and so on
+	levels := [][]node{nil}
+	push := func(n node) error {
+		levels[0] = append(levels[0], n)
+		for i := 0; len(levels[i]) == cfg.maxLinks; i++ {
+			parent, err := encodeFileNode(levels[i], yield)
+			if err != nil {
+				return err
+			}
+			levels[i] = nil
+			if i+1 == len(levels) {
+				levels = append(levels, nil)
+			}
+			levels[i+1] = append(levels[i+1], parent)
+		}
+		return nil
+	}
+
+	chunks := 0
+	buf := make([]byte, cfg.chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || chunks == 0 {
+			c, err := emit(yield, multicodec.Raw, slices.Clone(buf[:n]))
+			if err != nil {
+				return node{}, err
+			}
+			chunks++
+			if err := push(node{link: c, size: uint64(n), dagSize: uint64(n)}); err != nil {
+				return node{}, err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return node{}, fmt.Errorf("reading file: %s", err)
+		}
+	}
+
+	// link the remaining nodes at each level from a parent, until there is a
+	// single root
+	for i := 0; ; i++ {
+		top := i == len(levels)-1
+		if top && len(levels[i]) == 1 {
+			return levels[i][0], nil
+		}
+		if len(levels[i]) == 0 {
+			continue
+		}
+		parent, err := encodeFileNode(levels[i], yield)
+		if err != nil {
+			return node{}, err
+		}
+		if top {
+			return parent, nil
+		}
+		levels[i+1] = append(levels[i+1], parent)
+	}
+}
+
+func encodeFileNode(children []node, yield yieldFunc) (node, error) {
+	var size, dagSize uint64
+	d := data{Type: typeFile}
+	links := make([]pbLink, 0, len(children))
+	for _, c := range children {
+		size += c.size
+		dagSize += c.dagSize
+		d.BlockSizes = append(d.BlockSizes, c.size)
+		links = append(links, pbLink{Hash: c.link, Tsize: c.dagSize})
+	}
+	d.FileSize = &size
+
+	b := encodeNode(links, d.encode())
+	c, err := emit(yield, multicodec.DagPb, b)
+	if err != nil {
+		return node{}, err
+	}
+	return node{link: c, size: size, dagSize: dagSize + uint64(len(b))}, nil
+}
+
+// tree is a directory of files to encode.
+type tree struct {
+	files map[string]File
+	dirs  map[string]*tree
+	// order is the order entries were added in
+	order []string
+}
+
+func newTree(files []File) (*tree, error) {
+	root := &tree{files: map[string]File{}, dirs: map[string]*tree{}}
+	for _, f := range files {
+		parts := strings.Split(strings.Trim(f.Path, "/"), "/")
+		dir := root
+		for i, name := range parts {
+			if name == "" || name == "." || name == ".." {
+				return nil, fmt.Errorf("invalid file path: %q", f.Path)
+			}
+			if i == len(parts)-1 {
+				if _, ok := dir.files[name]; ok {
+					return nil, fmt.Errorf("duplicate file path: %q", f.Path)
+				}
+				if _, ok := dir.dirs[name]; ok {
+					return nil, fmt.Errorf("file path is also a directory: %q", f.Path)
+				}
+				dir.files[name] = f
+				dir.order = append(dir.order, name)
+				break
+			}
+			if _, ok := dir.files[name]; ok {
+				return nil, fmt.Errorf("file path is also a directory: %q", strings.Join(parts[:i+1], "/"))
+			}
+			sub, ok := dir.dirs[name]
+			if !ok {
+				sub = &tree{files: map[string]File{}, dirs: map[string]*tree{}}
+				dir.dirs[name] = sub
+				dir.order = append(dir.order, name)
+			}
+			dir = sub
+		}
+	}
+	return root, nil
+}
+
+// entry is a named entry in an encoded directory.
+type entry struct {
+	name string
+	node node
+}
+
+func encodeDirectory(cfg importerConfig, dir *tree, yield yieldFunc) (node, error) {
+	entries := make([]entry, 0, len(dir.order))
+	for _, name := range dir.order {
+		var n node
+		var err error
+		if sub, ok := dir.dirs[name]; ok {
+			n, err = encodeDirectory(cfg, sub, yield)
+		} else {
+			n, err = encodeDirectoryFile(cfg, dir.files[name], yield)
+		}
+		if err != nil {
+			return node{}, err
+		}
+		entries = append(entries, entry{name, n})
+	}
+	if len(entries) > cfg.shardThreshold {
+		return encodeShardedDirectory(entries, yield)
+	}
+	return encodeDirectoryNode(entries, yield)
+}
+
+func encodeDirectoryFile(cfg importerConfig, f File, yield yieldFunc) (node, error) {
+	r, err := f.Open()
+	if err != nil {
+		return node{}, fmt.Errorf("opening %s: %s", f.Path, err)
+	}
+	defer r.Close()
+	n, err := encodeFile(cfg, r, yield)
+	if err != nil && !errors.Is(err, errStopped) {
+		return node{}, fmt.Errorf("encoding %s: %w", f.Path, err)
+	}
+	return n, err
+}
+
+// encodeDirectoryNode encodes a directory node. Links are sorted by name, as
+// dag-pb requires.
+func encodeDirectoryNode(entries []entry, yield yieldFunc) (node, error) {
+	slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.name, b.name) })
+
+	var size, dagSize uint64
+	links := make([]pbLink, 0, len(entries))
+	for _, e := range entries {
+		size += e.node.size
+		dagSize += e.node.dagSize
+		links = append(links, pbLink{Hash: e.node.link, Name: e.name, Tsize: e.node.dagSize})
+	}
+
+	b := encodeNode(links, data{Type: typeDirectory}.encode())
+	c, err := emit(yield, multicodec.DagPb, b)
+	if err != nil {
+		return node{}, err
+	}
+	return node{link: c, size: size, dagSize: dagSize + uint64(len(b))}, nil
+}
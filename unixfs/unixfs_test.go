@@ -0,0 +1,195 @@
+package unixfs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	dagpb "github.com/ipld/go-codec-dagpb"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-w3up/unixfs"
+	"github.com/stretchr/testify/require"
+)
+
+func content(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+func collect(t *testing.T, blocks func(yield func(ipld.Block, error) bool)) []ipld.Block {
+	t.Helper()
+	var blks []ipld.Block
+	for blk, err := range blocks {
+		require.NoError(t, err)
+		blks = append(blks, blk)
+	}
+	require.NotEmpty(t, blks)
+	return blks
+}
+
+func root(blks []ipld.Block) string {
+	return blks[len(blks)-1].Link().String()
+}
+
+func file(path string, b []byte) unixfs.File {
+	return unixfs.File{Path: path, Open: func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }}
+}
+
+// fixtures are the CIDs the test content encodes to.
+type fixtures struct {
+	Generator         string         `json:"generator"`
+	File              map[int]string `json:"file"`
+	FileDefaults      string         `json:"fileDefaults"`
+	Directory         string         `json:"directory"`
+	DirectorySubdir   string         `json:"directorySubdir"`
+	ShardedDirectory  string         `json:"shardedDirectory"`
+	ShardedThreshold4 map[int]string `json:"shardedThreshold4"`
+	ShardedNested     string         `json:"shardedNested"`
+}
+
+func readFixtures(t *testing.T, path string) fixtures {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var f fixtures
+	require.NoError(t, json.Unmarshal(b, &f))
+	return f
+}
+
+// loadFixtures returns the CIDs the w3up JS client encodes the test content
+// to, written to testdata/fixtures.json by testdata/generate/generate.mjs. The
+// test is skipped if they have not been generated.
+func loadFixtures(t *testing.T) fixtures {
+	t.Helper()
+	if _, err := os.Stat("testdata/fixtures.json"); errors.Is(err, fs.ErrNotExist) {
+		t.Skip("testdata/fixtures.json not generated: run `npm install && npm run generate` in testdata/generate")
+	}
+	f := readFixtures(t, "testdata/fixtures.json")
+	require.True(t, strings.HasPrefix(f.Generator, "@web3-storage/upload-client "), "fixtures not generated by @web3-storage/upload-client: %q", f.Generator)
+	return f
+}
+
+// loadKuboFixtures returns the CIDs kubo encodes the test content to,
+// re-encoded without empty link names. They are not the JS client's output,
+// but pin the encoding of the inputs kubo and the JS importers agree on.
+func loadKuboFixtures(t *testing.T) fixtures {
+	t.Helper()
+	f := readFixtures(t, "testdata/kubo.json")
+	require.True(t, strings.HasPrefix(f.Generator, "kubo "), "fixtures not generated by kubo: %q", f.Generator)
+	return f
+}
+
+// withFixtures runs the test against the CIDs of the w3up JS client and of
+// kubo.
+func withFixtures(t *testing.T, test func(t *testing.T, expected fixtures)) {
+	t.Run("upload-client", func(t *testing.T) { test(t, loadFixtures(t)) })
+	t.Run("kubo", func(t *testing.T) { test(t, loadKuboFixtures(t)) })
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("boom") }
+
+func TestEncodeFile(t *testing.T) {
+	small := []unixfs.Option{unixfs.WithChunkSize(1024), unixfs.WithMaxLinks(4)}
+	withFixtures(t, func(t *testing.T, expected fixtures) {
+		require.NotEmpty(t, expected.File)
+		for size, cid := range expected.File {
+			blks := collect(t, unixfs.EncodeFile(bytes.NewReader(content(size)), small...))
+			require.Equal(t, cid, root(blks), "file of %d bytes", size)
+		}
+
+		t.Run("defaults", func(t *testing.T) {
+			blks := collect(t, unixfs.EncodeFile(bytes.NewReader(content(3*1024*1024+1))))
+			require.Equal(t, expected.FileDefaults, root(blks))
+			// 4 raw leaves and the root
+			require.Len(t, blks, 5)
+		})
+	})
+
+	t.Run("unnamed links", func(t *testing.T) {
+		blks := collect(t, unixfs.EncodeFile(bytes.NewReader(content(2048)), small...))
+		nb := dagpb.Type.PBNode.NewBuilder()
+		require.NoError(t, dagpb.DecodeBytes(nb, blks[len(blks)-1].Bytes()))
+		links := nb.Build().(dagpb.PBNode).FieldLinks()
+		require.Equal(t, int64(2), links.Length())
+		for it := links.Iterator(); !it.Done(); {
+			_, l := it.Next()
+			require.False(t, l.FieldName().Exists())
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		r := io.MultiReader(bytes.NewReader(content(2048)), errReader{})
+		var err error
+		for _, err = range unixfs.EncodeFile(r, small...) {
+			if err != nil {
+				break
+			}
+		}
+		require.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		n := 0
+		for range unixfs.EncodeFile(bytes.NewReader(content(50000)), small...) {
+			n++
+			if n == 3 {
+				break
+			}
+		}
+		require.Equal(t, 3, n)
+	})
+}
+
+func TestEncodeDirectory(t *testing.T) {
+	small := []unixfs.Option{unixfs.WithChunkSize(1024), unixfs.WithMaxLinks(4)}
+
+	files := []unixfs.File{
+		file("b/c.txt", content(10)),
+		file("a.txt", content(5000)),
+		file("b/d.txt", content(3000)),
+		file("empty", nil),
+	}
+
+	withFixtures(t, func(t *testing.T, expected fixtures) {
+		blks := collect(t, unixfs.EncodeDirectory(files, small...))
+		require.Equal(t, expected.Directory, root(blks))
+
+		var links []string
+		for _, blk := range blks {
+			links = append(links, blk.Link().String())
+		}
+		require.Contains(t, links, expected.DirectorySubdir)
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		for _, paths := range [][]string{
+			{"a", "a"},
+			{"a", "a/b"},
+			{"a/b", "a"},
+			{"a/../b"},
+			{""},
+		} {
+			var files []unixfs.File
+			for _, p := range paths {
+				files = append(files, file(p, nil))
+			}
+			var err error
+			for _, err = range unixfs.EncodeDirectory(files) {
+				if err != nil {
+					break
+				}
+			}
+			require.Error(t, err, paths)
+		}
+	})
+}